@@ -0,0 +1,73 @@
+package agilecrm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Address is the structured value of the address property, which the API
+// stores as a json string inside the property's value
+type Address struct {
+	Address string `json:"address,omitempty"`
+	City    string `json:"city,omitempty"`
+	State   string `json:"state,omitempty"`
+	Zip     string `json:"zip,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// IsZero reports whether every field of the address is empty
+func (a Address) IsZero() bool {
+	return a == Address{}
+}
+
+// ParseAddress decodes the value of an address property. An empty value is
+// an empty address.
+func ParseAddress(v string) (Address, error) {
+	a := Address{}
+	if v == "" {
+		return a, nil
+	}
+
+	if err := json.Unmarshal([]byte(v), &a); err != nil {
+		return Address{}, fmt.Errorf("address property is not a json address: %w", err)
+	}
+	return a, nil
+}
+
+// String encodes the address the way the address property stores it
+func (a Address) String() string {
+	bits, _ := json.Marshal(a)
+	return string(bits)
+}
+
+// Address decodes the address property with the subtype, an empty subtype
+// matching any. A missing property is an empty address.
+func (pl PropertyList) Address(subtype string) (Address, error) {
+	return ParseAddress(pl.Value(PropAddress, subtype))
+}
+
+// SetAddress stores the address in the property with the subtype. An empty
+// address leaves the property with an empty value, which is how the API is
+// told to clear it, since properties that aren't sent are left untouched.
+func (pl *PropertyList) SetAddress(subtype string, a Address) {
+	v := ""
+	if !a.IsZero() {
+		v = a.String()
+	}
+	pl.Set(Property{Name: PropAddress, Subtype: subtype, Value: v})
+}
+
+// PostalAddress decodes the contact's address
+func (c Contact) PostalAddress() (Address, error) {
+	return c.Properties.Address("")
+}
+
+// SetPostalAddress replaces the contact's address. It is sent along with the
+// other properties by CreateContact and UpdateContactProperties.
+func (c *Contact) SetPostalAddress(a Address) {
+	if p, ok := c.Properties.Get(PropAddress, ""); ok {
+		c.Properties.SetAddress(p.Subtype, a)
+		return
+	}
+	c.Properties.SetAddress("", a)
+}
@@ -0,0 +1,67 @@
+package agilecrm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+func TestPostalAddressRoundTrip(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		set  agilecrm.Address
+	}{
+		{name: "set", set: agilecrm.Address{Address: "1 Main St", City: "Springfield", Zip: "12345"}},
+		{name: "change", set: agilecrm.Address{City: "Shelbyville"}},
+		{name: "clear", set: agilecrm.Address{}},
+	}
+
+	ctc := newContact(t, cl, "address@example.com")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctc.SetPostalAddress(tt.set)
+			if _, err := cl.UpdateContactProperties(int64(ctc.ID), *ctc); err != nil {
+				t.Fatalf("unable to update; %v", err)
+			}
+
+			stored, err := cl.FindContactById(int(ctc.ID))
+			if err != nil {
+				t.Fatalf("unable to fetch; %v", err)
+			}
+			got, err := stored.PostalAddress()
+			if err != nil {
+				t.Fatalf("unable to decode address; %v", err)
+			}
+			if got != tt.set {
+				t.Errorf("address = %+v, want %+v", got, tt.set)
+			}
+			ctc = stored
+		})
+	}
+}
+
+func TestSetAddressClearSendsValue(t *testing.T) {
+	ctc := agilecrm.Contact{}
+	ctc.SetPostalAddress(agilecrm.Address{City: "Springfield"})
+	ctc.SetPostalAddress(agilecrm.Address{})
+
+	bits, err := json.Marshal(ctc.Properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw := []map[string]interface{}{}
+	if err := json.Unmarshal(bits, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 1 {
+		t.Fatalf("properties = %s, want only the address", bits)
+	}
+	if v, ok := raw[0]["value"]; !ok || v != "" {
+		t.Errorf("address sent as %s, want an explicit empty value", bits)
+	}
+}
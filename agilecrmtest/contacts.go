@@ -0,0 +1,449 @@
+package agilecrmtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// AddContact stores a contact as is, assigning it an ID when it has none,
+// and returns the stored copy
+func (s *Server) AddContact(in agilecrm.Contact) agilecrm.Contact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.storeContact(in)
+}
+
+// Contact returns the stored contact or company with the given ID
+func (s *Server) Contact(id agilecrm.ID) (agilecrm.Contact, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.contacts[id]
+	if !ok {
+		return agilecrm.Contact{}, false
+	}
+	return *c, true
+}
+
+// Contacts returns every stored contact and company ordered by ID
+func (s *Server) Contacts() []agilecrm.Contact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []agilecrm.Contact{}
+	for _, c := range s.listContacts("") {
+		out = append(out, *c)
+	}
+	return out
+}
+
+// storeContact ...
+func (s *Server) storeContact(in agilecrm.Contact) *agilecrm.Contact {
+	if in.ID == 0 {
+		in.ID = s.newID()
+	}
+	if in.Type == "" {
+		in.Type = agilecrm.TypeContact
+	}
+	if in.CreatedAt == 0 {
+		in.CreatedAt = now()
+	}
+	in.Cursor = ""
+
+	s.contacts[in.ID] = &in
+	return &in
+}
+
+// listContacts returns the stored contacts of the given type, all of them
+// when it is empty
+func (s *Server) listContacts(typ agilecrm.ContactType) []*agilecrm.Contact {
+	ids := []agilecrm.ID{}
+	for id, c := range s.contacts {
+		if typ == "" || c.Type == typ {
+			ids = append(ids, id)
+		}
+	}
+
+	out := []*agilecrm.Contact{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.contacts[id])
+	}
+	return out
+}
+
+// contactByEmail ...
+func (s *Server) contactByEmail(email string) *agilecrm.Contact {
+	for _, c := range s.listContacts("") {
+		for _, p := range c.Properties {
+			if p.Name == "email" && strings.EqualFold(p.Value, email) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// contactList returns copies of the contacts, which are safe to encode
+func contactList(cl []*agilecrm.Contact) agilecrm.ContactList {
+	out := agilecrm.ContactList{}
+	for _, c := range cl {
+		cp := *c
+		out = append(out, &cp)
+	}
+	return out
+}
+
+// linkedContacts resolves the string ids used by notes, tasks and the like
+func (s *Server) linkedContacts(ids []string) agilecrm.ContactList {
+	cl := []*agilecrm.Contact{}
+	for _, id := range parseIDs(ids) {
+		if c, ok := s.contacts[id]; ok {
+			cl = append(cl, c)
+		}
+	}
+	return contactList(cl)
+}
+
+// writeContactPage answers with a page of contacts, or a 204 when there are
+// none at all
+func writeContactPage(w http.ResponseWriter, r *http.Request, cl []*agilecrm.Contact) {
+	if len(cl) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	start, end, next := paginate(r, len(cl))
+	out := contactList(cl[start:end])
+	if next != "" && len(out) > 0 {
+		out[len(out)-1].Cursor = next
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// serveContacts ...
+func (s *Server) serveContacts(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		writeContactPage(w, r, s.listContacts(agilecrm.TypeContact))
+
+	case len(parts) == 0 && r.Method == "POST":
+		s.createContact(w, r)
+
+	case len(parts) == 2 && parts[0] == "companies" && parts[1] == "list" && r.Method == "POST":
+		writeContactPage(w, r, s.listContacts(agilecrm.TypeCompany))
+
+	case len(parts) == 2 && parts[0] == "search" && parts[1] == "email" && r.Method == "POST":
+		s.searchEmails(w, r)
+
+	case len(parts) == 3 && parts[0] == "search" && parts[1] == "email" && r.Method == "GET":
+		c := s.contactByEmail(parts[2])
+		if c == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return true
+		}
+		writeJSON(w, http.StatusOK, c)
+
+	case len(parts) == 1 && parts[0] == "edit-properties" && r.Method == "PUT":
+		s.editContact(w, r, editProperties)
+
+	case len(parts) == 2 && parts[0] == "edit" && parts[1] == "lead-score" && r.Method == "PUT":
+		s.editContact(w, r, func(c, in *agilecrm.Contact) { c.LeadScore = in.LeadScore })
+
+	case len(parts) == 2 && parts[0] == "edit" && parts[1] == "add-star" && r.Method == "PUT":
+		s.editContact(w, r, func(c, in *agilecrm.Contact) { c.StarValue = in.StarValue })
+
+	case len(parts) == 2 && parts[0] == "edit" && parts[1] == "tags" && r.Method == "PUT":
+		s.editContact(w, r, addTags)
+
+	case len(parts) == 2 && parts[0] == "delete" && parts[1] == "tags" && r.Method == "PUT":
+		s.editContact(w, r, deleteTags)
+
+	case len(parts) == 2 && parts[0] == "related" && r.Method == "GET":
+		id, ok := parseID(parts[1])
+		if !ok {
+			return false
+		}
+		out := []*agilecrm.Contact{}
+		for _, c := range s.listContacts(agilecrm.TypeContact) {
+			if c.ContactCompanyId == id {
+				out = append(out, c)
+			}
+		}
+		writeContactPage(w, r, out)
+
+	case len(parts) == 1 && parts[0] == "add-score" && r.Method == "POST":
+		s.changeScore(w, r, 1)
+
+	case len(parts) == 1 && parts[0] == "subtract-score" && r.Method == "POST":
+		s.changeScore(w, r, -1)
+
+	case len(parts) == 3 && parts[0] == "email" && parts[1] == "note" && parts[2] == "add" && r.Method == "POST":
+		s.addNoteByEmail(w, r)
+
+	case len(parts) >= 1:
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		return s.serveContact(w, r, id, parts[1:])
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// serveContact handles the routes below api/contacts/{id}
+func (s *Server) serveContact(w http.ResponseWriter, r *http.Request, id agilecrm.ID, parts []string) bool {
+	c, ok := s.contacts[id]
+
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return true
+		}
+		writeJSON(w, http.StatusOK, c)
+
+	case len(parts) == 0 && r.Method == "DELETE":
+		if !ok {
+			writeError(w, http.StatusNotFound, "no contact with that ID found")
+			return true
+		}
+		delete(s.contacts, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 1 && parts[0] == "notes" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.notesFor(func(n *agilecrm.Note) bool { return hasID(n.ContactIDs, id) }))
+
+	case len(parts) == 2 && parts[0] == "notes" && r.Method == "DELETE":
+		nid, _ := parseID(parts[1])
+		n, found := s.notes[nid]
+		if !found || !hasID(n.ContactIDs, id) {
+			writeError(w, http.StatusNotFound, "no such note")
+			return true
+		}
+		delete(s.notes, nid)
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 1 && parts[0] == "tasks" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.tasksFor(func(t *storedTask) bool { return hasID(t.ContactIDs, id) }))
+
+	case len(parts) == 1 && parts[0] == "deals" && r.Method == "GET":
+		out := []*agilecrm.Deal{}
+		for _, d := range s.listDeals() {
+			if hasID(d.ContactIds, id) {
+				out = append(out, d)
+			}
+		}
+		writeJSON(w, http.StatusOK, s.dealPage(r, out))
+
+	case len(parts) == 2 && parts[0] == "events" && parts[1] == "sort" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.eventsFor(func(e *storedEvent) bool { return hasID(e.Contacts, id) }))
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// createContact ...
+func (s *Server) createContact(w http.ResponseWriter, r *http.Request) {
+	in := agilecrm.Contact{}
+	if !readJSON(w, r, &in) {
+		return
+	}
+	in.ID = 0
+
+	for _, p := range in.Properties {
+		if p.Name == "email" && p.Value != "" && s.contactByEmail(p.Value) != nil {
+			writeError(w, http.StatusBadRequest, "Sorry, duplicate contact found with the same email address.")
+			return
+		}
+	}
+
+	in.UpdatedAt = 0
+	writeJSON(w, http.StatusOK, s.storeContact(in))
+}
+
+// editContact applies a partial update to the contact named by the body's id
+func (s *Server) editContact(w http.ResponseWriter, r *http.Request, edit func(c, in *agilecrm.Contact)) {
+	in := agilecrm.Contact{}
+	bits, ok := readPatch(w, r, &in)
+	if !ok {
+		return
+	}
+
+	c, ok := s.contacts[in.ID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "no contact with that ID found")
+		return
+	}
+
+	in.Properties = sentValues(bits, in.Properties)
+	// an email can only belong to one contact
+	for _, p := range in.Properties {
+		if p.Name != "email" || p.Value == "" {
+			continue
+		}
+		if other := s.contactByEmail(p.Value); other != nil && other.ID != c.ID {
+			writeError(w, http.StatusBadRequest, "Sorry, duplicate contact found with the same email address.")
+			return
+		}
+	}
+	edit(c, &in)
+
+	// the company link is only changed when it is sent, even when empty
+	fields := map[string]json.RawMessage{}
+	if json.Unmarshal(bits, &fields) == nil {
+		if _, found := fields["contact_company_id"]; found {
+			c.ContactCompanyId = in.ContactCompanyId
+		}
+	}
+	c.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, c)
+}
+
+// changeScore adds the score form value, times sign, to the lead score of
+// the contact with the email form value
+func (s *Server) changeScore(w http.ResponseWriter, r *http.Request, sign int) {
+	score, err := strconv.Atoi(r.FormValue("score"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "score must be a number")
+		return
+	}
+
+	c := s.contactByEmail(r.FormValue("email"))
+	if c == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	c.LeadScore += agilecrm.Int(sign * score)
+	c.UpdatedAt = now()
+	writeJSON(w, http.StatusOK, c)
+}
+
+// sentValues keeps the properties of the raw json that carry a value key;
+// the ones without one change nothing
+func sentValues(bits []byte, pl agilecrm.PropertyList) agilecrm.PropertyList {
+	raw := struct {
+		Properties []map[string]json.RawMessage `json:"properties"`
+	}{}
+	if json.Unmarshal(bits, &raw) != nil || len(raw.Properties) != len(pl) {
+		return pl
+	}
+
+	out := agilecrm.PropertyList{}
+	for i, p := range pl {
+		if _, ok := raw.Properties[i]["value"]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// editProperties replaces the properties with the same name and subtype,
+// adding the ones the contact doesn't have yet and clearing the ones sent
+// with an empty value. Emails, phones and websites are sent with all their
+// values, which replace the ones the contact had.
+func editProperties(c, in *agilecrm.Contact) {
+	replaced := map[string]bool{}
+	for _, p := range in.Properties {
+		if !multiValued(p.Name) || replaced[p.Name] {
+			continue
+		}
+		replaced[p.Name] = true
+		kept := agilecrm.PropertyList{}
+		for _, cur := range c.Properties {
+			if cur.Name != p.Name {
+				kept = append(kept, cur)
+			}
+		}
+		c.Properties = kept
+	}
+
+	for _, p := range in.Properties {
+		found := false
+		for i, cur := range c.Properties {
+			if !multiValued(p.Name) && cur.Name == p.Name && cur.Subtype == p.Subtype {
+				c.Properties[i] = p
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Properties = append(c.Properties, p)
+		}
+	}
+
+	// properties sent with an empty value are cleared
+	kept := agilecrm.PropertyList{}
+	for _, p := range c.Properties {
+		if p.Value != "" {
+			kept = append(kept, p)
+		}
+	}
+	c.Properties = kept
+}
+
+// multiValued ...
+func multiValued(name string) bool {
+	return name == "email" || name == "phone" || name == "website"
+}
+
+// addTags ...
+func addTags(c, in *agilecrm.Contact) {
+	for _, t := range in.Tags {
+		found := false
+		for _, cur := range c.Tags {
+			if cur == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.Tags = append(c.Tags, t)
+		}
+	}
+}
+
+// deleteTags ...
+func deleteTags(c, in *agilecrm.Contact) {
+	out := []string{}
+	for _, cur := range c.Tags {
+		keep := true
+		for _, t := range in.Tags {
+			if cur == t {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, cur)
+		}
+	}
+	c.Tags = out
+}
+
+// searchEmails answers the batch email lookup, which only lists the contacts
+// that were found
+func (s *Server) searchEmails(w http.ResponseWriter, r *http.Request) {
+	emails := []string{}
+	if err := json.Unmarshal([]byte(r.FormValue("email_ids")), &emails); err != nil {
+		writeError(w, http.StatusBadRequest, "email_ids must be a json list")
+		return
+	}
+
+	out := []*agilecrm.Contact{}
+	for _, e := range emails {
+		if c := s.contactByEmail(e); c != nil {
+			out = append(out, c)
+		}
+	}
+	writeJSON(w, http.StatusOK, contactList(out))
+}
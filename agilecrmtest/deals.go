@@ -0,0 +1,285 @@
+package agilecrmtest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// AddDeal stores a deal as is, assigning it an ID when it has none, and
+// returns the stored copy
+func (s *Server) AddDeal(in agilecrm.Deal) agilecrm.Deal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dealView(s.storeDeal(in))
+}
+
+// Deal returns the stored deal with the given ID
+func (s *Server) Deal(id agilecrm.ID) (agilecrm.Deal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deals[id]
+	if !ok {
+		return agilecrm.Deal{}, false
+	}
+	return s.dealView(d), true
+}
+
+// RemoveDeal deletes the stored deal with the given ID, reporting whether
+// there was one
+func (s *Server) RemoveDeal(id agilecrm.ID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.deals[id]
+	delete(s.deals, id)
+	return ok
+}
+
+// Deals returns every stored deal ordered by ID
+func (s *Server) Deals() []agilecrm.Deal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []agilecrm.Deal{}
+	for _, d := range s.listDeals() {
+		out = append(out, s.dealView(d))
+	}
+	return out
+}
+
+// storeDeal ...
+func (s *Server) storeDeal(in agilecrm.Deal) *agilecrm.Deal {
+	if in.ID == 0 {
+		in.ID = s.newID()
+	}
+	if in.CreatedTime == 0 {
+		in.CreatedTime = now()
+	}
+	if in.PipelineID == 0 {
+		in.PipelineID = s.defaultPipelineID()
+	}
+	if in.OwnerID == 0 {
+		in.OwnerID = UserID
+	}
+	in.Contacts = nil
+	in.Cursor = ""
+
+	s.deals[in.ID] = &in
+	return &in
+}
+
+// listDeals ...
+func (s *Server) listDeals() []*agilecrm.Deal {
+	ids := []agilecrm.ID{}
+	for id := range s.deals {
+		ids = append(ids, id)
+	}
+
+	out := []*agilecrm.Deal{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.deals[id])
+	}
+	return out
+}
+
+// dealView returns a copy of the deal with its contacts resolved
+func (s *Server) dealView(d *agilecrm.Deal) agilecrm.Deal {
+	out := *d
+	out.Contacts = s.linkedContacts(d.ContactIds)
+	return out
+}
+
+// writeDealPage answers with a page of deals, or a 204 when there are none
+// at all
+func (s *Server) writeDealPage(w http.ResponseWriter, r *http.Request, dl []*agilecrm.Deal) {
+	if len(dl) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.dealPage(r, dl))
+}
+
+// dealPage returns the requested page of the deals, its last deal carrying
+// the cursor of the next one
+func (s *Server) dealPage(r *http.Request, dl []*agilecrm.Deal) agilecrm.DealList {
+	start, end, next := paginate(r, len(dl))
+	out := agilecrm.DealList{}
+	for _, d := range dl[start:end] {
+		out = append(out, s.dealView(d))
+	}
+	if next != "" && len(out) > 0 {
+		out[len(out)-1].Cursor = next
+	}
+	return out
+}
+
+// serveDeals ...
+func (s *Server) serveDeals(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		s.writeDealPage(w, r, s.listDeals())
+
+	case len(parts) == 0 && r.Method == "POST":
+		in := agilecrm.Deal{}
+		if !readJSON(w, r, &in) {
+			return true
+		}
+		in.ID = 0
+		writeJSON(w, http.StatusOK, s.dealView(s.storeDeal(in)))
+
+	case len(parts) == 1 && parts[0] == "partial-update" && r.Method == "PUT":
+		s.updateDeal(w, r)
+
+	case len(parts) == 1 && parts[0] == "based" && r.Method == "POST":
+		s.serveDealFilter(w, r)
+
+	case len(parts) == 2 && parts[0] == "deals" && parts[1] == "notes" && r.Method == "PUT":
+		s.createNote(w, r)
+
+	case len(parts) >= 1 && parts[0] == "bulk" && r.Method == "POST":
+		return s.bulkDeals(w, r, parts[1:])
+
+	case len(parts) == 2 && parts[0] == "email" && r.Method == "POST":
+		s.createDealForEmail(w, r, parts[1])
+
+	case len(parts) == 2 && parts[0] == "my" && parts[1] == "deals" && r.Method == "GET":
+		out := []*agilecrm.Deal{}
+		for _, d := range s.listDeals() {
+			if d.OwnerID == UserID {
+				out = append(out, d)
+			}
+		}
+		writeJSON(w, http.StatusOK, s.dealPage(r, out))
+
+	case len(parts) >= 1:
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		return s.serveDeal(w, r, id, parts[1:])
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// serveDeal handles the routes below api/opportunity/{id}
+func (s *Server) serveDeal(w http.ResponseWriter, r *http.Request, id agilecrm.ID, parts []string) bool {
+	d, ok := s.deals[id]
+
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return true
+		}
+		writeJSON(w, http.StatusOK, s.dealView(d))
+
+	case len(parts) == 0 && r.Method == "DELETE":
+		if !ok {
+			writeError(w, http.StatusNotFound, "no deal with that ID found")
+			return true
+		}
+		delete(s.deals, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	case len(parts) == 1 && parts[0] == "notes" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.notesFor(func(n *agilecrm.Note) bool { return hasID(n.DealIDs, id) }))
+
+	default:
+		return false
+	}
+
+	return true
+}
+
+// updateDeal only changes the fields present in the body
+func (s *Server) updateDeal(w http.ResponseWriter, r *http.Request) {
+	in := struct {
+		ID agilecrm.ID `json:"id"`
+	}{}
+	bits, ok := readPatch(w, r, &in)
+	if !ok {
+		return
+	}
+
+	d, ok := s.deals[in.ID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "no deal with that ID found")
+		return
+	}
+
+	if err := merge(d, bits); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	d.Contacts = nil
+	writeJSON(w, http.StatusOK, s.dealView(d))
+}
+
+// createDealForEmail creates a deal linked to the contact with the email
+func (s *Server) createDealForEmail(w http.ResponseWriter, r *http.Request, email string) {
+	in := agilecrm.Deal{}
+	if !readJSON(w, r, &in) {
+		return
+	}
+
+	c := s.contactByEmail(email)
+	if c == nil {
+		writeError(w, http.StatusBadRequest, "no contact with that email found")
+		return
+	}
+
+	in.ID = 0
+	if !hasID(in.ContactIds, c.ID) {
+		in.ContactIds = append(in.ContactIds, formatID(c.ID))
+	}
+	writeJSON(w, http.StatusOK, s.dealView(s.storeDeal(in)))
+}
+
+// bulkDeals handles the bulk routes, which change every deal listed in the
+// ids form value and skip the ones that don't exist
+func (s *Server) bulkDeals(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	var change func(d *agilecrm.Deal)
+	switch {
+	case len(parts) == 0:
+		change = func(d *agilecrm.Deal) { delete(s.deals, d.ID) }
+
+	case len(parts) == 2 && parts[0] == "change-owner":
+		owner, ok := parseID(parts[1])
+		if !ok {
+			return false
+		}
+		change = func(d *agilecrm.Deal) { d.OwnerID = owner }
+
+	case len(parts) == 1 && parts[0] == "change-milestone":
+		pipeline, ok := parseID(r.FormValue("pipeline_id"))
+		milestone := r.FormValue("milestone")
+		if !ok || milestone == "" {
+			writeError(w, http.StatusBadRequest, "pipeline_id and milestone are required")
+			return true
+		}
+		change = func(d *agilecrm.Deal) {
+			d.PipelineID = pipeline
+			d.Milestone = milestone
+		}
+
+	default:
+		return false
+	}
+
+	ids := []string{}
+	if err := json.Unmarshal([]byte(r.FormValue("ids")), &ids); err != nil {
+		writeError(w, http.StatusBadRequest, "ids must be a json list")
+		return true
+	}
+	for _, id := range parseIDs(ids) {
+		if d, ok := s.deals[id]; ok {
+			change(d)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
@@ -0,0 +1,104 @@
+package agilecrmtest
+
+import (
+	"net/http"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// storedDoc keeps documents in the shape they are written in
+type storedDoc struct {
+	agilecrm.UpsertDoc
+	UploadedTime agilecrm.Time `json:"uploaded_time"`
+}
+
+// Documents returns every stored document ordered by ID
+func (s *Server) Documents() agilecrm.DocumentList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docsFor(func(*storedDoc) bool { return true })
+}
+
+// docsFor returns the documents matching the predicate
+func (s *Server) docsFor(match func(*storedDoc) bool) agilecrm.DocumentList {
+	ids := []agilecrm.ID{}
+	for id, d := range s.docs {
+		if match(d) {
+			ids = append(ids, id)
+		}
+	}
+
+	out := agilecrm.DocumentList{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.docView(s.docs[id]))
+	}
+	return out
+}
+
+// docView builds the document the way the API returns it
+func (s *Server) docView(d *storedDoc) agilecrm.Document {
+	deals := agilecrm.DealList{}
+	for _, id := range parseIDs(d.DealIds) {
+		if deal, ok := s.deals[id]; ok {
+			deals = append(deals, s.dealView(deal))
+		}
+	}
+
+	return agilecrm.Document{
+		ID:           agilecrm.ID(*d.ID),
+		Name:         d.Name,
+		UploadedTime: d.UploadedTime,
+		Extension:    d.Extension,
+		DocType:      d.DocType,
+		Size:         agilecrm.Int(d.Size),
+		NetworkType:  d.NetworkType,
+		URL:          d.URL,
+		EntityType:   "document",
+		ContactIds:   d.ContactIds,
+		DealIds:      d.DealIds,
+		Contacts:     s.linkedContacts(d.ContactIds),
+		Deals:        deals,
+	}
+}
+
+// serveDocuments ...
+func (s *Server) serveDocuments(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	switch {
+	case len(parts) == 0 && r.Method == "POST":
+		in := storedDoc{}
+		if !readJSON(w, r, &in.UpsertDoc) {
+			return true
+		}
+		id := s.newID()
+		raw := int64(id)
+		in.ID = &raw
+		in.UploadedTime = now()
+		s.docs[id] = &in
+		writeJSON(w, http.StatusOK, s.docView(&in))
+
+	case len(parts) == 0 && r.Method == "PUT":
+		in := agilecrm.UpsertDoc{}
+		if !readJSON(w, r, &in) {
+			return true
+		}
+		if in.ID == nil || s.docs[agilecrm.ID(*in.ID)] == nil {
+			writeError(w, http.StatusBadRequest, "no document with that ID found")
+			return true
+		}
+		d := s.docs[agilecrm.ID(*in.ID)]
+		d.UpsertDoc = in
+		writeJSON(w, http.StatusOK, s.docView(d))
+
+	case len(parts) == 3 && parts[0] == "contact" && parts[2] == "docs" && r.Method == "GET":
+		id, ok := parseID(parts[1])
+		if !ok {
+			return false
+		}
+		writeJSON(w, http.StatusOK, s.docsFor(func(d *storedDoc) bool { return hasID(d.ContactIds, id) }))
+
+	default:
+		return false
+	}
+
+	return true
+}
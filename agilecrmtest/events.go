@@ -0,0 +1,114 @@
+package agilecrmtest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// storedEvent keeps events in the shape they are written in
+type storedEvent struct {
+	agilecrm.EventUpsert
+}
+
+// Events returns every stored event ordered by ID
+func (s *Server) Events() agilecrm.EventList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.eventsFor(func(*storedEvent) bool { return true })
+}
+
+// eventsFor returns the events matching the predicate
+func (s *Server) eventsFor(match func(*storedEvent) bool) agilecrm.EventList {
+	ids := []agilecrm.ID{}
+	for id, e := range s.events {
+		if match(e) {
+			ids = append(ids, id)
+		}
+	}
+
+	out := agilecrm.EventList{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.eventView(s.events[id]))
+	}
+	return out
+}
+
+// eventView builds the event the way the API returns it
+func (s *Server) eventView(e *storedEvent) agilecrm.Event {
+	return agilecrm.Event{
+		ID:             agilecrm.ID(*e.ID),
+		CreatedTime:    e.CreatedTime,
+		AllDay:         e.AllDay,
+		Title:          e.Title,
+		Color:          e.Color,
+		Start:          e.Start,
+		End:            e.End,
+		IsEventStarred: e.IsEventStarred,
+		Contacts:       s.linkedContacts(e.Contacts),
+	}
+}
+
+// serveEvents ...
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		start, _ := strconv.ParseInt(r.FormValue("start"), 10, 64)
+		end, err := strconv.ParseInt(r.FormValue("end"), 10, 64)
+		if err != nil {
+			end = math.MaxInt64
+		}
+		writeJSON(w, http.StatusOK, s.eventsFor(func(e *storedEvent) bool {
+			return e.Start.Unix() >= start && e.Start.Unix() <= end
+		}))
+
+	case len(parts) == 0 && r.Method == "POST":
+		in := storedEvent{}
+		if !readJSON(w, r, &in.EventUpsert) {
+			return true
+		}
+		id := s.newID()
+		raw := int64(id)
+		in.ID = &raw
+		if in.CreatedTime == 0 {
+			in.CreatedTime = now()
+		}
+		s.events[id] = &in
+		writeJSON(w, http.StatusOK, s.eventView(&in))
+
+	case len(parts) == 0 && r.Method == "PUT":
+		in := agilecrm.EventUpsert{}
+		if !readJSON(w, r, &in) {
+			return true
+		}
+		if in.ID == nil || s.events[agilecrm.ID(*in.ID)] == nil {
+			writeError(w, http.StatusBadRequest, "no event with that ID found")
+			return true
+		}
+		e := s.events[agilecrm.ID(*in.ID)]
+		if in.CreatedTime == 0 {
+			in.CreatedTime = e.CreatedTime
+		}
+		e.EventUpsert = in
+		writeJSON(w, http.StatusOK, s.eventView(e))
+
+	case len(parts) == 1 && r.Method == "DELETE":
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		if _, found := s.events[id]; !found {
+			writeError(w, http.StatusNotFound, "no event with that ID found")
+			return true
+		}
+		delete(s.events, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,88 @@
+package agilecrmtest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// Notes returns every stored note ordered by ID
+func (s *Server) Notes() agilecrm.NoteList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notesFor(func(*agilecrm.Note) bool { return true })
+}
+
+// storeNote ...
+func (s *Server) storeNote(in agilecrm.Note) *agilecrm.Note {
+	in.ID = s.newID()
+	if in.CreatedTime == 0 {
+		in.CreatedTime = now()
+	}
+	in.Count = nil
+	in.Contacts = nil
+
+	s.notes[in.ID] = &in
+	return &in
+}
+
+// notesFor returns copies of the notes matching the predicate, with their
+// contacts resolved
+func (s *Server) notesFor(match func(*agilecrm.Note) bool) agilecrm.NoteList {
+	ids := []agilecrm.ID{}
+	for id, n := range s.notes {
+		if match(n) {
+			ids = append(ids, id)
+		}
+	}
+
+	out := agilecrm.NoteList{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.noteView(s.notes[id]))
+	}
+	return out
+}
+
+// noteView ...
+func (s *Server) noteView(n *agilecrm.Note) *agilecrm.Note {
+	out := *n
+	out.Contacts = s.linkedContacts(n.ContactIDs)
+	return &out
+}
+
+// serveNotes ...
+func (s *Server) serveNotes(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	if len(parts) != 0 || r.Method != "POST" {
+		return false
+	}
+	s.createNote(w, r)
+	return true
+}
+
+// createNote ...
+func (s *Server) createNote(w http.ResponseWriter, r *http.Request) {
+	in := agilecrm.Note{}
+	if !readJSON(w, r, &in) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.noteView(s.storeNote(in)))
+}
+
+// addNoteByEmail attaches a note to the contact with the form's email
+func (s *Server) addNoteByEmail(w http.ResponseWriter, r *http.Request) {
+	c := s.contactByEmail(r.FormValue("email"))
+	if c == nil {
+		writeError(w, http.StatusBadRequest, "no contact with that email found")
+		return
+	}
+
+	in := agilecrm.Note{}
+	if err := json.Unmarshal([]byte(r.FormValue("note")), &in); err != nil {
+		writeError(w, http.StatusBadRequest, "note must be json")
+		return
+	}
+	in.ContactIDs = []string{formatID(c.ID)}
+
+	writeJSON(w, http.StatusOK, s.noteView(s.storeNote(in)))
+}
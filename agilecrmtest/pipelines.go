@@ -0,0 +1,129 @@
+package agilecrmtest
+
+import (
+	"net/http"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// DefaultPipelineID is the ID of the pipeline every server starts with,
+// which has the milestones AgileCRM gives new accounts
+const DefaultPipelineID agilecrm.ID = 1
+
+// defaultPipeline ...
+func defaultPipeline() *agilecrm.Pipeline {
+	return &agilecrm.Pipeline{
+		ID:            DefaultPipelineID,
+		Name:          "Default",
+		Milestones:    agilecrm.Milestones{"New", "Prospect", "Proposal", "Won", "Lost"},
+		WonMilestone:  "Won",
+		LostMilestone: "Lost",
+		IsDefault:     true,
+	}
+}
+
+// AddPipeline stores a pipeline as is, assigning it an ID when it has none,
+// and returns the stored copy
+func (s *Server) AddPipeline(p agilecrm.Pipeline) agilecrm.Pipeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.ID == 0 {
+		p.ID = s.newID()
+	}
+	s.pipelines[p.ID] = &p
+	return p
+}
+
+// Pipelines returns every stored pipeline ordered by ID
+func (s *Server) Pipelines() agilecrm.PipelineList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listPipelines()
+}
+
+// listPipelines ...
+func (s *Server) listPipelines() agilecrm.PipelineList {
+	ids := []agilecrm.ID{}
+	for id := range s.pipelines {
+		ids = append(ids, id)
+	}
+
+	out := agilecrm.PipelineList{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, *s.pipelines[id])
+	}
+	return out
+}
+
+// defaultPipelineID returns the pipeline deals are put in when they are
+// created without one
+func (s *Server) defaultPipelineID() agilecrm.ID {
+	pl := s.listPipelines()
+	if p, ok := pl.Default(); ok {
+		return p.ID
+	}
+	return 0
+}
+
+// servePipelines handles the routes below api/milestone
+func (s *Server) servePipelines(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	if len(parts) == 0 || parts[0] != "pipelines" {
+		return false
+	}
+	parts = parts[1:]
+
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.listPipelines())
+
+	case len(parts) == 0 && r.Method == "POST":
+		in := agilecrm.Pipeline{}
+		if !readJSON(w, r, &in) {
+			return true
+		}
+		in.ID = s.newID()
+		s.pipelines[in.ID] = &in
+		writeJSON(w, http.StatusOK, in)
+
+	case len(parts) == 0 && r.Method == "PUT":
+		in := agilecrm.Pipeline{}
+		if !readJSON(w, r, &in) {
+			return true
+		}
+		if s.pipelines[in.ID] == nil {
+			writeError(w, http.StatusBadRequest, "no pipeline with that ID found")
+			return true
+		}
+		s.pipelines[in.ID] = &in
+		writeJSON(w, http.StatusOK, in)
+
+	case len(parts) == 1 && r.Method == "GET":
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		p, found := s.pipelines[id]
+		if !found {
+			w.WriteHeader(http.StatusNoContent)
+			return true
+		}
+		writeJSON(w, http.StatusOK, p)
+
+	case len(parts) == 1 && r.Method == "DELETE":
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		if _, found := s.pipelines[id]; !found {
+			writeError(w, http.StatusNotFound, "no pipeline with that ID found")
+			return true
+		}
+		delete(s.pipelines, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		return false
+	}
+
+	return true
+}
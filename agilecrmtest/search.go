@@ -0,0 +1,294 @@
+package agilecrmtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+type filterRule struct {
+	Left      string `json:"LHS"`
+	Condition string `json:"CONDITION"`
+	Right     string `json:"RHS"`
+	RightNew  string `json:"RHS_NEW"`
+}
+
+type filterJson struct {
+	Rules       []filterRule         `json:"rules"`
+	OrRules     []filterRule         `json:"or_rules"`
+	ContactType agilecrm.ContactType `json:"contact_type"`
+}
+
+// serveFilters ...
+func (s *Server) serveFilters(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	if len(parts) != 2 || parts[0] != "filter" || parts[1] != "dynamic-filter" || r.Method != "POST" {
+		return false
+	}
+
+	fj := filterJson{}
+	if err := json.Unmarshal([]byte(r.FormValue("filterJson")), &fj); err != nil {
+		writeError(w, http.StatusBadRequest, "filterJson must be json")
+		return true
+	}
+
+	out := []*agilecrm.Contact{}
+	for _, c := range s.listContacts(fj.ContactType) {
+		if fj.match(func(lhs string) []string { return contactValues(c, lhs) }) {
+			out = append(out, c)
+		}
+	}
+	sortContacts(out, r.FormValue("global_sort_key"))
+
+	start, end, next := paginate(r, len(out))
+	page := contactList(out[start:end])
+	if next != "" && len(page) > 0 {
+		page[len(page)-1].Cursor = next
+	}
+	writeJSON(w, http.StatusOK, page)
+	return true
+}
+
+// serveSearch answers keyword searches, matching the query against the
+// property values and tags of contacts, or the name and description of deals
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	if len(parts) != 0 || r.Method != "GET" {
+		return false
+	}
+
+	q := strings.ToLower(strings.TrimSpace(r.FormValue("q")))
+	typ := agilecrm.ContactType(strings.Trim(r.FormValue("type"), "'\""))
+
+	if typ == agilecrm.TypeDeal {
+		out := []*agilecrm.Deal{}
+		for _, d := range s.listDeals() {
+			if contains(q, d.Name, d.Description) {
+				out = append(out, d)
+			}
+		}
+		s.writeDealPage(w, r, out)
+		return true
+	}
+
+	out := []*agilecrm.Contact{}
+	for _, c := range s.listContacts(typ) {
+		values := append([]string{}, c.Tags...)
+		for _, p := range c.Properties {
+			values = append(values, p.Value)
+		}
+		if contains(q, values...) {
+			out = append(out, c)
+		}
+	}
+	writeContactPage(w, r, out)
+	return true
+}
+
+// contains reports whether any of the values contains the lowercase query
+func contains(q string, values ...string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// match checks every rule, and at least one of the or rules when there are
+// any, against the values of a record's fields
+func (fj filterJson) match(values func(lhs string) []string) bool {
+	for _, r := range fj.Rules {
+		if !r.match(values(r.Left)) {
+			return false
+		}
+	}
+
+	if len(fj.OrRules) == 0 {
+		return true
+	}
+	for _, r := range fj.OrRules {
+		if r.match(values(r.Left)) {
+			return true
+		}
+	}
+	return false
+}
+
+// match ...
+func (r filterRule) match(values []string) bool {
+	switch r.Condition {
+	case "DEFINED":
+		return len(values) > 0
+	case "NOT_DEFINED":
+		return len(values) == 0
+	case "NOTEQUALS":
+		return !anyValue(values, func(v string) bool { return strings.EqualFold(v, r.Right) })
+	case "NOT_CONTAINS":
+		return !anyValue(values, func(v string) bool { return contains(strings.ToLower(r.Right), v) })
+	}
+
+	return anyValue(values, func(v string) bool { return r.compare(v) })
+}
+
+// compare checks a single value against the rule
+func (r filterRule) compare(v string) bool {
+	switch r.Condition {
+	case "EQUALS":
+		return strings.EqualFold(v, r.Right)
+	case "CONTAINS":
+		return contains(strings.ToLower(r.Right), v)
+	}
+
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return false
+	}
+	rhs, _ := strconv.ParseFloat(r.Right, 64)
+	rhsNew, _ := strconv.ParseFloat(r.RightNew, 64)
+
+	// date values are epoch millis
+	day := float64(24 * time.Hour / time.Millisecond)
+	nowMillis := float64(time.Now().UnixNano() / int64(time.Millisecond))
+
+	switch r.Condition {
+	case "IS_GREATER_THAN", "AFTER":
+		return n > rhs
+	case "IS_LESS_THAN", "BEFORE":
+		return n < rhs
+	case "BETWEEN":
+		return n >= rhs && n <= rhsNew
+	case "ON":
+		return n >= rhs && n < rhs+day
+	case "LAST":
+		return n <= nowMillis && n >= nowMillis-rhs*day
+	case "NEXT":
+		return n >= nowMillis && n <= nowMillis+rhs*day
+	}
+	return false
+}
+
+// anyValue ...
+func anyValue(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// millis formats a time the way filters compare it, leaving out zero times
+func millis(t agilecrm.Time) []string {
+	if t.IsZero() {
+		return nil
+	}
+	return []string{strconv.FormatInt(t.Millis(), 10)}
+}
+
+// contactValues returns the non-empty values a rule's left hand side refers
+// to, with times as epoch millis
+func contactValues(c *agilecrm.Contact, lhs string) []string {
+	number := func(n int) []string {
+		return []string{strconv.Itoa(n)}
+	}
+
+	switch lhs {
+	case "tags":
+		return c.Tags
+	case "created_time":
+		return millis(c.CreatedAt)
+	case "updated_time":
+		return millis(c.UpdatedAt)
+	case "lead_score":
+		return number(int(c.LeadScore))
+	case "star_value":
+		return number(int(c.StarValue))
+	}
+
+	out := []string{}
+	for _, p := range c.Properties {
+		if p.Name == lhs && p.Value != "" {
+			out = append(out, p.Value)
+		}
+	}
+	return out
+}
+
+// sortContacts orders the contacts by a global_sort_key like
+// "-created_time"
+func sortContacts(cl []*agilecrm.Contact, key string) {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	field := func(c *agilecrm.Contact) int64 {
+		switch key {
+		case "created_time":
+			return c.CreatedAt.Unix()
+		case "updated_time":
+			return c.UpdatedAt.Unix()
+		}
+		return int64(c.ID)
+	}
+
+	sort.SliceStable(cl, func(i, j int) bool {
+		if desc {
+			return field(cl[i]) > field(cl[j])
+		}
+		return field(cl[i]) < field(cl[j])
+	})
+}
+
+// dealValues is contactValues for deals
+func dealValues(d *agilecrm.Deal, lhs string) []string {
+	nonEmpty := func(v string) []string {
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	}
+
+	switch lhs {
+	case "tags":
+		return d.Tags
+	case "name":
+		return nonEmpty(d.Name)
+	case "milestone":
+		return nonEmpty(d.Milestone)
+	case "pipeline", "pipeline_id":
+		return nonEmpty(formatID(d.PipelineID))
+	case "owner_id":
+		return nonEmpty(formatID(d.OwnerID))
+	case "expected_value":
+		return []string{strconv.FormatFloat(float64(d.ExpectedValue), 'f', -1, 64)}
+	case "probability":
+		return []string{strconv.Itoa(int(d.Probabilty))}
+	case "close_date":
+		return millis(d.CloseDate)
+	case "created_time":
+		return millis(d.CreatedTime)
+	}
+	return nil
+}
+
+// serveDealFilter answers the deal filter, which takes the same filterJson
+// as the contact one
+func (s *Server) serveDealFilter(w http.ResponseWriter, r *http.Request) {
+	fj := filterJson{}
+	if err := json.Unmarshal([]byte(r.FormValue("filterJson")), &fj); err != nil {
+		writeError(w, http.StatusBadRequest, "filterJson must be json")
+		return
+	}
+
+	out := []*agilecrm.Deal{}
+	for _, d := range s.listDeals() {
+		if fj.match(func(lhs string) []string { return dealValues(d, lhs) }) {
+			out = append(out, d)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.dealPage(r, out))
+}
@@ -0,0 +1,393 @@
+// Package agilecrmtest provides an in-memory stand-in for the AgileCRM API,
+// so code using the agilecrm client can be exercised without network access.
+//
+//	srv := agilecrmtest.NewServer()
+//	defer srv.Close()
+//
+//	cl, err := agilecrm.New(srv.Config())
+package agilecrmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+const (
+	// User and Password are the credentials accepted by a new Server
+	User     = "test@example.com"
+	Password = "agilecrmtest"
+
+	// UserID is the ID of the domain user the credentials belong to, who
+	// owns the deals created without an owner
+	UserID agilecrm.ID = 1
+
+	// defaultPageSize is used by paged routes called without a page_size
+	defaultPageSize = 25
+
+	routePrefix = "/dev/"
+)
+
+// Fault makes the server answer matching requests with an error instead of
+// handling them.
+type Fault struct {
+	// Method and Route select the requests to fail. Route is relative to the
+	// API root, like "api/contacts/12", and matches any route it prefixes.
+	// An empty Method matches every method.
+	Method string
+	Route  string
+
+	// Status and Message are sent back as the response status and the
+	// "exception message" of the body
+	Status  int
+	Message string
+
+	// RetryAfter, when set, is sent as the Retry-After header
+	RetryAfter string
+
+	// Times is how many requests fail before the fault is removed; zero
+	// keeps it until ClearFaults is called
+	Times int
+
+	// Before, when set, is called before the error is sent back, e.g. to
+	// change the store the way a request that fails part way through would.
+	// It may call the Server's methods.
+	Before func()
+}
+
+// Server is a fake AgileCRM API backed by an in-memory store.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	user     string
+	pass     string
+	nextID   agilecrm.ID
+	requests int
+	faults   []*Fault
+
+	contacts map[agilecrm.ID]*agilecrm.Contact
+	deals    map[agilecrm.ID]*agilecrm.Deal
+	notes    map[agilecrm.ID]*agilecrm.Note
+	tasks    map[agilecrm.ID]*storedTask
+	events   map[agilecrm.ID]*storedEvent
+	docs     map[agilecrm.ID]*storedDoc
+
+	pipelines map[agilecrm.ID]*agilecrm.Pipeline
+}
+
+// NewServer starts a server accepting the package's User and Password
+func NewServer() *Server {
+	s := &Server{
+		user:     User,
+		pass:     Password,
+		nextID:   1000,
+		contacts: map[agilecrm.ID]*agilecrm.Contact{},
+		deals:    map[agilecrm.ID]*agilecrm.Deal{},
+		notes:    map[agilecrm.ID]*agilecrm.Note{},
+		tasks:    map[agilecrm.ID]*storedTask{},
+		events:   map[agilecrm.ID]*storedEvent{},
+		docs:     map[agilecrm.ID]*storedDoc{},
+
+		pipelines: map[agilecrm.ID]*agilecrm.Pipeline{
+			DefaultPipelineID: defaultPipeline(),
+		},
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL returns the API root to use as agilecrm.Config.BaseURL
+func (s *Server) URL() string {
+	return s.srv.URL + routePrefix
+}
+
+// Config returns a client config pointing at the server
+func (s *Server) Config() agilecrm.Config {
+	return agilecrm.Config{
+		BaseURL:  s.URL(),
+		User:     s.user,
+		Password: s.pass,
+	}
+}
+
+// Client returns a client for the server, panicking if it can't be built
+func (s *Server) Client() *agilecrm.Client {
+	cl, err := agilecrm.New(s.Config())
+	if err != nil {
+		panic(fmt.Sprintf("agilecrmtest: unable to create client; %v", err))
+	}
+	return cl
+}
+
+// SetCredentials changes the user and password the server accepts
+func (s *Server) SetCredentials(user, pass string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = user
+	s.pass = pass
+}
+
+// Inject adds a fault to the server
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every injected fault
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Requests returns the number of requests the server has received
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// serveHTTP ...
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if u, p, ok := r.BasicAuth(); !ok || u != s.user || p != s.pass {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, routePrefix) {
+		writeError(w, http.StatusNotFound, "no such route")
+		return
+	}
+	route := strings.TrimPrefix(r.URL.Path, routePrefix)
+
+	if f := s.fault(r.Method, route); f != nil {
+		if f.Before != nil {
+			s.mu.Unlock()
+			f.Before()
+			s.mu.Lock()
+		}
+		if f.RetryAfter != "" {
+			w.Header().Set("Retry-After", f.RetryAfter)
+		}
+		writeError(w, f.Status, f.Message)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(route, "/"), "/")
+	if len(parts) < 2 || parts[0] != "api" {
+		writeError(w, http.StatusNotFound, "no such route")
+		return
+	}
+
+	var handled bool
+	switch parts[1] {
+	case "contacts":
+		handled = s.serveContacts(w, r, parts[2:])
+	case "opportunity":
+		handled = s.serveDeals(w, r, parts[2:])
+	case "notes":
+		handled = s.serveNotes(w, r, parts[2:])
+	case "tasks":
+		handled = s.serveTasks(w, r, parts[2:])
+	case "events":
+		handled = s.serveEvents(w, r, parts[2:])
+	case "documents":
+		handled = s.serveDocuments(w, r, parts[2:])
+	case "filters":
+		handled = s.serveFilters(w, r, parts[2:])
+	case "search":
+		handled = s.serveSearch(w, r, parts[2:])
+	case "milestone":
+		handled = s.servePipelines(w, r, parts[2:])
+	}
+
+	if !handled {
+		writeError(w, http.StatusNotFound, "no such route")
+	}
+}
+
+// fault returns the first fault matching the request, consuming one of its
+// uses
+func (s *Server) fault(method, route string) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, method) {
+			continue
+		}
+		if !strings.HasPrefix(route, strings.Trim(f.Route, "/")) {
+			continue
+		}
+
+		out := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &out
+	}
+	return nil
+}
+
+// newID ...
+func (s *Server) newID() agilecrm.ID {
+	s.nextID++
+	return s.nextID
+}
+
+// now ...
+func now() agilecrm.Time {
+	return agilecrm.NewTime(time.Now())
+}
+
+// writeJSON ...
+func writeJSON(w http.ResponseWriter, st int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(st)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError ...
+func writeError(w http.ResponseWriter, st int, msg string) {
+	writeJSON(w, st, map[string]string{
+		"status":            strconv.Itoa(st),
+		"exception message": msg,
+	})
+}
+
+// readJSON decodes the request body, answering with a 400 when it can't
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	bits, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(bits, v)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid input; %v", err))
+		return false
+	}
+	return true
+}
+
+// readPatch is readJSON for partial updates, also returning the raw body so
+// it can be merged onto the stored record
+func readPatch(w http.ResponseWriter, r *http.Request, v interface{}) ([]byte, bool) {
+	bits, err := ioutil.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(bits, v)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid input; %v", err))
+		return nil, false
+	}
+	return bits, true
+}
+
+// merge overlays the fields present in the raw json onto v, which is how the
+// API's partial updates behave
+func merge(v interface{}, patch []byte) error {
+	cur, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(cur, &fields); err != nil {
+		return err
+	}
+
+	changes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return err
+	}
+	for k, c := range changes {
+		fields[k] = c
+	}
+
+	bits, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bits, v)
+}
+
+// parseID ...
+func parseID(v string) (agilecrm.ID, bool) {
+	id, err := strconv.ParseInt(v, 10, 64)
+	return agilecrm.ID(id), err == nil
+}
+
+// parseIDs converts the string ids used by the API's link fields
+func parseIDs(ids []string) []agilecrm.ID {
+	out := make([]agilecrm.ID, 0, len(ids))
+	for _, v := range ids {
+		if id, ok := parseID(v); ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// formatID ...
+func formatID(id agilecrm.ID) string {
+	return id.String()
+}
+
+// hasID ...
+func hasID(ids []string, id agilecrm.ID) bool {
+	for _, v := range ids {
+		if v == formatID(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortIDs ...
+func sortIDs(ids []agilecrm.ID) []agilecrm.ID {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// paginate returns the bounds of the requested page and the cursor of the
+// next one, which is empty on the last page
+func paginate(r *http.Request, total int) (int, int, string) {
+	size, err := strconv.Atoi(r.FormValue("page_size"))
+	if err != nil || size <= 0 {
+		size = defaultPageSize
+	}
+
+	start, err := strconv.Atoi(r.FormValue("cursor"))
+	if err != nil || start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+
+	end := start + size
+	if end >= total {
+		return start, total, ""
+	}
+	return start, end, strconv.Itoa(end)
+}
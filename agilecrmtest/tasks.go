@@ -0,0 +1,147 @@
+package agilecrmtest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// storedTask keeps tasks in the shape they are written in, since the API
+// reads and writes them differently
+type storedTask struct {
+	agilecrm.TaskCreate
+	CreatedTime agilecrm.Time `json:"created_time"`
+}
+
+// Tasks returns every stored task ordered by ID
+func (s *Server) Tasks() agilecrm.TaskList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tasksFor(func(*storedTask) bool { return true })
+}
+
+// tasksFor returns the tasks matching the predicate
+func (s *Server) tasksFor(match func(*storedTask) bool) agilecrm.TaskList {
+	ids := []agilecrm.ID{}
+	for id, t := range s.tasks {
+		if match(t) {
+			ids = append(ids, id)
+		}
+	}
+
+	out := agilecrm.TaskList{}
+	for _, id := range sortIDs(ids) {
+		out = append(out, s.taskView(s.tasks[id]))
+	}
+	return out
+}
+
+// taskView builds the task the way the API returns it
+func (s *Server) taskView(t *storedTask) agilecrm.Task {
+	notes := agilecrm.NoteList{}
+	for _, id := range parseIDs(t.NoteIDs) {
+		if n, ok := s.notes[id]; ok {
+			notes = append(notes, s.noteView(n))
+		}
+	}
+
+	out := agilecrm.Task{
+		ID:           agilecrm.ID(*t.ID),
+		Type:         string(t.Type),
+		PriorityType: string(t.PriorityType),
+		Due:          t.Due,
+		CreatedTime:  t.CreatedTime,
+		IsComplete:   t.IsComplete,
+		Subject:      t.Subject,
+		Progress:     agilecrm.Int(t.Progress),
+		Status:       string(t.Status),
+		Contacts:     s.linkedContacts(t.ContactIDs),
+		Notes:        notes,
+		EntityType:   "task",
+	}
+	if t.OwnerID != 0 {
+		out.TaskOwner = &agilecrm.TaskOwner{ID: agilecrm.ID(t.OwnerID)}
+	}
+	return out
+}
+
+// serveTasks ...
+func (s *Server) serveTasks(w http.ResponseWriter, r *http.Request, parts []string) bool {
+	switch {
+	case len(parts) == 0 && r.Method == "GET":
+		writeJSON(w, http.StatusOK, s.tasksFor(func(*storedTask) bool { return true }))
+
+	case len(parts) == 0 && r.Method == "POST":
+		in := storedTask{}
+		if !readJSON(w, r, &in.TaskCreate) {
+			return true
+		}
+		id := s.newID()
+		raw := int64(id)
+		in.ID = &raw
+		in.CreatedTime = now()
+		s.tasks[id] = &in
+		writeJSON(w, http.StatusOK, s.taskView(&in))
+
+	case len(parts) == 1 && parts[0] == "partial-update" && r.Method == "PUT":
+		in := agilecrm.TaskCreate{}
+		bits, ok := readPatch(w, r, &in)
+		if !ok {
+			return true
+		}
+		var t *storedTask
+		if in.ID != nil {
+			t = s.tasks[agilecrm.ID(*in.ID)]
+		}
+		if t == nil {
+			writeError(w, http.StatusBadRequest, "no task with that ID found")
+			return true
+		}
+		if err := merge(&t.TaskCreate, bits); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return true
+		}
+		writeJSON(w, http.StatusOK, s.taskView(t))
+
+	case len(parts) == 2 && parts[0] == "pending" && r.Method == "GET":
+		days, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return false
+		}
+		until := agilecrm.NewTime(time.Now().AddDate(0, 0, days))
+		writeJSON(w, http.StatusOK, s.tasksFor(func(t *storedTask) bool {
+			return !t.IsComplete && t.Due <= until
+		}))
+
+	case len(parts) == 1 && r.Method == "GET":
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		t, found := s.tasks[id]
+		if !found {
+			w.WriteHeader(http.StatusNoContent)
+			return true
+		}
+		writeJSON(w, http.StatusOK, s.taskView(t))
+
+	case len(parts) == 1 && r.Method == "DELETE":
+		id, ok := parseID(parts[0])
+		if !ok {
+			return false
+		}
+		if _, found := s.tasks[id]; !found {
+			writeError(w, http.StatusNotFound, "no task with that ID found")
+			return true
+		}
+		delete(s.tasks, id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		return false
+	}
+
+	return true
+}
@@ -2,11 +2,13 @@ package agilecrm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -17,14 +19,30 @@ var ErrWrongFormat = fmt.Errorf("input in wrong format")
 var ErrContactLimit = fmt.Errorf("limit of contacts exceeded")
 var ErrNoContacts = fmt.Errorf("no contacts in account")
 var ErrNoSuchContact = fmt.Errorf("no contact with that ID found")
+var ErrStarValue = fmt.Errorf("star value must be between 0 and 5")
+var ErrDuplicateEmail = fmt.Errorf("more than one contact has that email")
+
+// ErrNoSuchEmail is returned by email lookups. It also matches
+// ErrNoSuchContact under errors.Is, so either can be checked for.
+var ErrNoSuchEmail error = &subError{msg: "no contact with that email found", parent: ErrNoSuchContact}
 
 const apiURLf = "https://%v.agilecrm.com/dev/"
 
 // TODO: hoist all route bases to here to make them consts
 
 type Client struct {
-	url string
-	ht  http.Client
+	url   string
+	ht    http.Client
+	retry RetryPolicy
+	limit *limiter
+
+	upserts *keyedMutex
+
+	lenient       bool
+	onDecodeError func(*DecodeError)
+
+	validateMilestones bool
+	verifyBulk         bool
 }
 
 // route ...
@@ -32,27 +50,107 @@ func (c *Client) route(r string) string {
 	return fmt.Sprintf("%v%v", c.url, r)
 }
 
+// defaultTimeout is used for every request when Config.Timeout is zero
+const defaultTimeout = time.Second * 10
+
 type Config struct {
 	Domain        string
 	User          string
 	Password      string
 	DefaultClient http.RoundTripper
+
+	// BaseURL replaces the API URL built from Domain, e.g. to point the client
+	// at a local test server. It must be an absolute http or https URL; a
+	// trailing slash is added when missing.
+	BaseURL string
+
+	// Timeout bounds every request made by the client, on top of any deadline
+	// carried by the context passed to the *Context methods. Zero uses a 10
+	// second timeout, a negative value disables it.
+	Timeout time.Duration
+
+	// Retry sets how transient failures are retried. The zero value makes a
+	// single attempt per request.
+	Retry RetryPolicy
+
+	// RateLimit caps the requests per second made through the client, across
+	// all goroutines sharing it, allowing bursts of up to RateBurst requests.
+	// Zero disables the limit.
+	RateLimit float64
+	RateBurst int
+
+	// LenientDecode keeps responses that only partly decode, like a list
+	// with one malformed element, instead of failing the call. Each field
+	// that couldn't be decoded is passed to OnDecodeError, if set.
+	LenientDecode bool
+	OnDecodeError func(*DecodeError)
+
+	// ValidateMilestones makes CreateDeal and UpdateDeal check the deal's
+	// milestone is part of its pipeline before sending it, at the cost of
+	// looking the pipeline up first
+	ValidateMilestones bool
+
+	// VerifyBulk makes the bulk deal methods read every deal back after the
+	// API's bulk endpoint answers, to report the ones it didn't change, like
+	// deals that don't exist. It costs a request per deal.
+	VerifyBulk bool
 }
 
 // New ...
 func New(conf Config) (*Client, error) {
-	if conf.Domain == "" {
-		return nil, fmt.Errorf("domain is required in config")
+	url, err := baseURL(conf)
+	if err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf(apiURLf, conf.Domain)
-
 	cl, err := getClient(conf)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{url: url, ht: cl}, nil
+	return &Client{
+		url:   url,
+		ht:    cl,
+		retry: conf.Retry,
+		limit: newLimiter(conf.RateLimit, conf.RateBurst),
+
+		upserts: &keyedMutex{},
+
+		lenient:       conf.LenientDecode,
+		onDecodeError: conf.OnDecodeError,
+
+		validateMilestones: conf.ValidateMilestones,
+		verifyBulk:         conf.VerifyBulk,
+	}, nil
+}
+
+// baseURL ...
+func baseURL(conf Config) (string, error) {
+	if conf.BaseURL == "" {
+		if conf.Domain == "" {
+			return "", fmt.Errorf("domain is required in config")
+		}
+		return fmt.Sprintf(apiURLf, conf.Domain), nil
+	}
+
+	u, err := url.Parse(conf.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base url; %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("base url must use http or https")
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("base url must have a host")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("base url can't have a query or fragment")
+	}
+
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return u.String(), nil
 }
 
 func getClient(conf Config) (http.Client, error) {
@@ -63,20 +161,32 @@ func getClient(conf Config) (http.Client, error) {
 		return http.Client{}, ErrInvalidCreds
 	}
 
+	orig := conf.DefaultClient
+	if orig == nil {
+		orig = http.DefaultTransport
+	}
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	} else if timeout < 0 {
+		timeout = 0
+	}
+
 	return http.Client{
 		Transport: rt{
 			user: user,
 			pass: pass,
-			orig: conf.DefaultClient,
+			orig: orig,
 		},
-		Timeout: time.Second * 10,
+		Timeout: timeout,
 	}, nil
 }
 
 // req ...
-func (c *Client) req(method, route string, body io.Reader, params map[string]string) (*http.Request, error) {
+func (c *Client) req(ctx context.Context, method, route string, body io.Reader, params map[string]string) (*http.Request, error) {
 	r := c.route(route)
-	req, err := http.NewRequest(method, r, body)
+	req, err := http.NewRequestWithContext(ctx, method, r, body)
 	if err != nil {
 		return nil, err
 	}
@@ -98,8 +208,8 @@ func (c *Client) req(method, route string, body io.Reader, params map[string]str
 }
 
 // postForm ...
-func (c *Client) postForm(method, route string, body io.Reader, params map[string]string) (*http.Request, error) {
-	req, err := c.req(method, route, body, params)
+func (c *Client) postForm(ctx context.Context, method, route string, body io.Reader, params map[string]string) (*http.Request, error) {
+	req, err := c.req(ctx, method, route, body, params)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +219,7 @@ func (c *Client) postForm(method, route string, body io.Reader, params map[strin
 
 // _processResults ...
 func (c *Client) processResults(req *http.Request, out interface{}) (int, error) {
-	res, err := c.ht.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return -1, err
 	}
@@ -123,23 +233,15 @@ func (c *Client) processResults(req *http.Request, out interface{}) (int, error)
 	// fmt.Printf("status: %v\n", res.StatusCode)
 	// fmt.Printf("body: \n\n%v\n\n", string(resBody))
 
-	if len(resBody) <= 0 || res.StatusCode == http.StatusNoContent {
-		return res.StatusCode, nil
+	if res.StatusCode >= http.StatusBadRequest {
+		return res.StatusCode, c.apiError(req, res.StatusCode, resBody)
 	}
 
-	if res.StatusCode == http.StatusInternalServerError {
-		err := struct {
-			Msg  string `json:"exception message"`
-			Code string `json:"status"`
-		}{Msg: "unknown"}
-		x := json.Unmarshal(resBody, &err)
-		if x != nil {
-			fmt.Printf("unable to unmarshal error: %v\n", x)
-		}
-		return res.StatusCode, fmt.Errorf("request failed; %v", err.Msg)
+	if len(resBody) <= 0 || res.StatusCode == http.StatusNoContent {
+		return res.StatusCode, nil
 	}
 
-	err = json.Unmarshal(resBody, out)
+	err = c.decode(req, resBody, out)
 	if err != nil {
 		return -1, err
 	}
@@ -148,8 +250,8 @@ func (c *Client) processResults(req *http.Request, out interface{}) (int, error)
 }
 
 // _getContact ...
-func (c *Client) get(method, route string, body io.Reader, params map[string]string, out interface{}) (int, error) {
-	req, err := c.req(method, route, body, params)
+func (c *Client) get(ctx context.Context, method, route string, body io.Reader, params map[string]string, out interface{}) (int, error) {
+	req, err := c.req(ctx, method, route, body, params)
 	if err != nil {
 		return -1, err
 	}
@@ -158,46 +260,45 @@ func (c *Client) get(method, route string, body io.Reader, params map[string]str
 }
 
 // findByID ...
-func (c *Client) findByID(route string, out interface{}) error {
-	st, err := c.get("GET", route, nil, nil, &out)
-	if st == http.StatusOK && err == nil {
-		return nil
+func (c *Client) findByID(ctx context.Context, route string, out interface{}) error {
+	st, err := c.get(ctx, "GET", route, nil, nil, out)
+	if err != nil {
+		return err
 	}
 
-	switch st {
-	case http.StatusNoContent:
-		return ErrNoSuchContact
-	case http.StatusUnauthorized:
-		return ErrUnauthorized
+	if st == http.StatusNoContent {
+		return statusError("GET", route, st, ErrNoSuchContact)
 	}
 
-	return err
+	return nil
 }
 
 // _sendContact ...
-func (c *Client) send(method, route string, params map[string]string, in interface{}, out interface{}) (int, error) {
+func (c *Client) send(ctx context.Context, method, route string, params map[string]string, in interface{}, out interface{}) (int, error) {
 	bits, err := json.Marshal(in)
 	if err != nil {
 		return -1, err
 	}
 	buf := bytes.NewBuffer(bits)
-	return c.get(method, route, buf, params, out)
+	return c.get(ctx, method, route, buf, params, out)
 }
 
 // delete ...
-func (c *Client) delete(route string) error {
-	req, err := c.req("DELETE", route, nil, nil)
+func (c *Client) delete(ctx context.Context, route string) error {
+	req, err := c.req(ctx, "DELETE", route, nil, nil)
 	if err != nil {
 		return err
 	}
 
-	res, err := c.ht.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
-	if res.StatusCode != 204 {
-		return fmt.Errorf(res.Status)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return c.apiError(req, res.StatusCode, body)
 	}
 	return nil
 }
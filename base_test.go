@@ -0,0 +1,158 @@
+package agilecrm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+// newClient starts a fake server and returns a client for it, with conf
+// applied to the client's config when set. The caller closes the server.
+func newClient(t *testing.T, conf func(*agilecrm.Config)) (*agilecrmtest.Server, *agilecrm.Client) {
+	t.Helper()
+
+	srv := agilecrmtest.NewServer()
+	cfg := srv.Config()
+	if conf != nil {
+		conf(&cfg)
+	}
+
+	cl, err := agilecrm.New(cfg)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("unable to create client; %v", err)
+	}
+	return srv, cl
+}
+
+// newContact creates a person with the email
+func newContact(t *testing.T, cl *agilecrm.Client, email string) *agilecrm.Contact {
+	t.Helper()
+
+	in := agilecrm.Contact{}
+	in.SetEmail("", email)
+	ctc, err := cl.CreateContact(in)
+	if err != nil {
+		t.Fatalf("unable to create contact %v; %v", email, err)
+	}
+	return ctc
+}
+
+func TestContextCanceled(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+	ctc := newContact(t, cl, "canceled@example.com")
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"get", func(ctx context.Context) error {
+			_, err := cl.FindContactByIdContext(ctx, int(ctc.ID))
+			return err
+		}},
+		{"list", func(ctx context.Context) error {
+			_, err := cl.ListContactsContext(ctx, 0, "")
+			return err
+		}},
+		{"send", func(ctx context.Context) error {
+			_, err := cl.UpdateContactTagsContext(ctx, int64(ctc.ID), []string{"late"})
+			return err
+		}},
+		{"delete", func(ctx context.Context) error {
+			return cl.DeleteContactContext(ctx, int(ctc.ID))
+		}},
+		{"form", func(ctx context.Context) error {
+			_, err := cl.FindContactsByEmailContext(ctx, []string{"canceled@example.com"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			before := srv.Requests()
+			if err := tt.call(ctx); !errors.Is(err, context.Canceled) {
+				t.Fatalf("error = %v, want the context's", err)
+			}
+			if n := srv.Requests() - before; n != 0 {
+				t.Errorf("%v requests sent on a canceled context", n)
+			}
+		})
+	}
+
+	if _, ok := srv.Contact(ctc.ID); !ok {
+		t.Error("contact deleted on a canceled context")
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := cl.ListDealsContext(ctx, 0, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the context's", err)
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	srv := agilecrmtest.NewServer()
+	defer srv.Close()
+	ctc := srv.AddContact(agilecrm.Contact{})
+
+	tests := []struct {
+		name    string
+		base    string
+		domain  string
+		wantErr bool
+	}{
+		{name: "trailing slash", base: srv.URL()},
+		{name: "no trailing slash", base: strings.TrimSuffix(srv.URL(), "/")},
+		{name: "domain only", domain: "example"},
+		{name: "neither", wantErr: true},
+		{name: "not http", base: "ftp://example.com/dev/", wantErr: true},
+		{name: "relative", base: "/dev/", wantErr: true},
+		{name: "no host", base: "http:///dev/", wantErr: true},
+		{name: "query", base: srv.URL() + "?debug=1", wantErr: true},
+		{name: "fragment", base: srv.URL() + "#top", wantErr: true},
+		{name: "unparsable", base: "http://[::1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := srv.Config()
+			cfg.BaseURL = tt.base
+			cfg.Domain = tt.domain
+
+			cl, err := agilecrm.New(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an invalid config")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+
+			// only the server's URLs can be called without network access
+			if tt.base == "" {
+				return
+			}
+			if _, err := cl.FindContactById(int(ctc.ID)); err != nil {
+				t.Fatalf("unable to reach the server; %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package agilecrm
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The binder maps contact and company properties onto the fields of a
+// struct, driven by an agile tag on each field:
+//
+//	type Lead struct {
+//		Email    string    `agile:"email,subtype=work"`
+//		Plan     string    `agile:"Plan,custom"`
+//		Seats    int       `agile:"Seats,custom"`
+//		Trial    bool      `agile:"Trial,custom"`
+//		Renewal  time.Time `agile:"Renewal Date,custom"`
+//		Products []string  `agile:"Products,custom,omitempty"`
+//		Address  Address   `agile:"address"`
+//	}
+//
+// The first part of the tag is the property name. The options are custom or
+// system for the property's type (system when not given), subtype=... and
+// omitempty, which leaves zero values out when encoding. Fields without a
+// tag, or tagged "-", are ignored; embedded structs without a tag are bound
+// as if their fields were part of the outer struct.
+//
+// Strings, numbers, booleans, time.Time, Time and MilliTime, []string,
+// Address and pointers to those are supported. Booleans are written as "on"
+// and "off" the way checkbox fields store them, and read from any of on/off,
+// yes/no and strconv.ParseBool's forms. Dates are written as epoch seconds
+// (millis for MilliTime), and read from epoch seconds or millis, RFC 3339,
+// "2006-01-02" or "01/02/2006".
+// Multi-select lists are stored comma separated.
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	agileTimeType = reflect.TypeOf(Time(0))
+	milliTimeType = reflect.TypeOf(MilliTime(0))
+	addressType   = reflect.TypeOf(Address{})
+)
+
+// PropertyError is the failure to convert one field to or from its property
+type PropertyError struct {
+	Field    string
+	Property string
+	Value    string
+	Err      error
+}
+
+// Error ...
+func (e *PropertyError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("field %v (property %v): %v", e.Field, e.Property, e.Err)
+	}
+	return fmt.Sprintf("field %v (property %v) value %q: %v", e.Field, e.Property, e.Value, e.Err)
+}
+
+// Unwrap ...
+func (e *PropertyError) Unwrap() error {
+	return e.Err
+}
+
+// PropertyErrors lists every field that could not be converted. The other
+// fields are still decoded or encoded.
+type PropertyErrors []*PropertyError
+
+// Error ...
+func (pe PropertyErrors) Error() string {
+	if len(pe) == 1 {
+		return pe[0].Error()
+	}
+
+	msgs := make([]string, len(pe))
+	for i, e := range pe {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%v property errors: %v", len(pe), strings.Join(msgs, "; "))
+}
+
+// propertyTag is a parsed agile tag
+type propertyTag struct {
+	name      string
+	typ       string
+	subtype   string
+	omitEmpty bool
+}
+
+// parsePropertyTag ...
+func parsePropertyTag(tag string) (propertyTag, error) {
+	parts := strings.Split(tag, ",")
+	pt := propertyTag{name: strings.TrimSpace(parts[0]), typ: TypeSystem}
+	if pt.name == "" {
+		return pt, fmt.Errorf("agile tag %q has no property name", tag)
+	}
+
+	for _, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		switch {
+		case opt == "custom":
+			pt.typ = TypeCustom
+		case opt == "system":
+			pt.typ = TypeSystem
+		case opt == "omitempty":
+			pt.omitEmpty = true
+		case strings.HasPrefix(opt, "subtype="):
+			pt.subtype = strings.TrimPrefix(opt, "subtype=")
+		default:
+			return pt, fmt.Errorf("agile tag %q has unknown option %q", tag, opt)
+		}
+	}
+	return pt, nil
+}
+
+// boundField is a struct field with an agile tag
+type boundField struct {
+	name  string
+	tag   propertyTag
+	value reflect.Value
+}
+
+// boundFields walks the tagged fields of a struct, descending into untagged
+// embedded structs. Invalid tags are reported as errors.
+func boundFields(v reflect.Value, prefix string, errs *PropertyErrors) []boundField {
+	out := []boundField{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, tagged := f.Tag.Lookup("agile")
+		if tag == "-" {
+			continue
+		}
+
+		if !tagged {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				out = append(out, boundFields(v.Field(i), prefix+f.Name+".", errs)...)
+			}
+			continue
+		}
+
+		if f.PkgPath != "" {
+			*errs = append(*errs, &PropertyError{Field: prefix + f.Name, Property: tag, Err: fmt.Errorf("field is not exported")})
+			continue
+		}
+
+		pt, err := parsePropertyTag(tag)
+		if err != nil {
+			*errs = append(*errs, &PropertyError{Field: prefix + f.Name, Property: tag, Err: err})
+			continue
+		}
+		out = append(out, boundField{name: prefix + f.Name, tag: pt, value: v.Field(i)})
+	}
+	return out
+}
+
+// Decode copies the contact's properties into the tagged fields of the struct
+// v points to. Properties the contact doesn't have leave their fields
+// untouched. Conversion failures are returned as PropertyErrors.
+func (c Contact) Decode(v interface{}) error {
+	return c.Properties.Decode(v)
+}
+
+// Decode is Contact.Decode for a property list
+func (pl PropertyList) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode needs a non-nil pointer to a struct, got %T", v)
+	}
+
+	errs := PropertyErrors{}
+	for _, f := range boundFields(rv.Elem(), "", &errs) {
+		p, ok := pl.Get(f.tag.name, f.tag.subtype)
+		if !ok {
+			continue
+		}
+		if err := decodeProperty(f.value, p.Value); err != nil {
+			errs = append(errs, &PropertyError{Field: f.name, Property: f.tag.name, Value: p.Value, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// decodeProperty converts a property value into the field
+func decodeProperty(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Ptr {
+		if s == "" {
+			fv.Set(reflect.Zero(fv.Type()))
+			return nil
+		}
+		nv := reflect.New(fv.Type().Elem())
+		if err := decodeProperty(nv.Elem(), s); err != nil {
+			return err
+		}
+		fv.Set(nv)
+		return nil
+	}
+
+	switch fv.Type() {
+	case timeType:
+		t, err := parsePropertyTime(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case agileTimeType:
+		t, err := parsePropertyTime(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(NewTime(t)))
+		return nil
+	case milliTimeType:
+		t, err := parsePropertyTime(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(NewMilliTime(t)))
+		return nil
+	case addressType:
+		a, err := ParseAddress(s)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(a))
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := parsePropertyBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if s == "" {
+			fv.SetInt(0)
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("not an integer")
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s == "" {
+			fv.SetUint(0)
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("not an unsigned integer")
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if s == "" {
+			fv.SetFloat(0)
+			return nil
+		}
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("not a number")
+		}
+		fv.SetFloat(n)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %v", fv.Type())
+		}
+		list := reflect.MakeSlice(fv.Type(), 0, 0)
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = reflect.Append(list, reflect.ValueOf(item).Convert(fv.Type().Elem()))
+			}
+		}
+		fv.Set(list)
+	default:
+		return fmt.Errorf("unsupported type %v", fv.Type())
+	}
+	return nil
+}
+
+// parsePropertyBool ...
+func parsePropertyBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "", "off", "no", "n":
+		return false, nil
+	case "on", "yes", "y":
+		return true, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("not a boolean")
+	}
+	return b, nil
+}
+
+// propertyTimeLayouts are the date formats read besides epoch times
+var propertyTimeLayouts = []string{time.RFC3339, "2006-01-02", "01/02/2006"}
+
+// parsePropertyTime reads a date property, where numbers above 1e11 are
+// taken as epoch millis and smaller ones as epoch seconds
+func parsePropertyTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > millisThreshold {
+			return time.Unix(0, n*int64(time.Millisecond)), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+
+	for _, layout := range propertyTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("not a date")
+}
+
+// EncodeProperties converts the tagged fields of a struct, or a pointer to
+// one, into properties that can be given to CreateContact, CreateCompany or
+// UpdateContactProperties. Nil pointers are always left out, zero values
+// only for fields tagged omitempty.
+func EncodeProperties(v interface{}) (PropertyList, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("encode needs a struct or a pointer to one, got %T", v)
+	}
+
+	out := PropertyList{}
+	errs := PropertyErrors{}
+	for _, f := range boundFields(rv, "", &errs) {
+		fv := f.value
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		} else if f.tag.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		s, err := encodeProperty(fv)
+		if err != nil {
+			errs = append(errs, &PropertyError{Field: f.name, Property: f.tag.name, Err: err})
+			continue
+		}
+		out = append(out, Property{Name: f.tag.name, Type: f.tag.typ, Subtype: f.tag.subtype, Value: s})
+	}
+
+	if len(errs) > 0 {
+		return out, errs
+	}
+	return out, nil
+}
+
+// encodeProperty formats a field as a property value
+func encodeProperty(fv reflect.Value) (string, error) {
+	switch fv.Type() {
+	case timeType:
+		t := fv.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case agileTimeType, milliTimeType:
+		if fv.Int() == 0 {
+			return "", nil
+		}
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case addressType:
+		a := fv.Interface().(Address)
+		if a.IsZero() {
+			return "", nil
+		}
+		return a.String(), nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		if fv.Bool() {
+			return "on", nil
+		}
+		return "off", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return "", fmt.Errorf("unsupported type %v", fv.Type())
+		}
+		items := make([]string, fv.Len())
+		for i := range items {
+			items[i] = fv.Index(i).String()
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported type %v", fv.Type())
+}
@@ -0,0 +1,316 @@
+package agilecrm_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+type leadSource struct {
+	Source string `agile:"Source,custom"`
+}
+
+type lead struct {
+	leadSource
+
+	Email    string             `agile:"email,subtype=work"`
+	Plan     string             `agile:"Plan,custom"`
+	Seats    int                `agile:"Seats,custom"`
+	Ratio    float64            `agile:"Ratio,custom"`
+	Count    uint               `agile:"Count,custom"`
+	Trial    bool               `agile:"Trial,custom"`
+	Renewal  time.Time          `agile:"Renewal Date,custom"`
+	Signed   agilecrm.Time      `agile:"Signed,custom"`
+	Seen     agilecrm.MilliTime `agile:"Seen,custom"`
+	Products []string           `agile:"Products,custom,omitempty"`
+	Address  agilecrm.Address   `agile:"address"`
+	Manager  *string            `agile:"Manager,custom"`
+	Budget   *int               `agile:"Budget,custom"`
+	Skipped  string             `agile:"-"`
+	Untagged string
+}
+
+func TestDecode(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	ann, five := "Ann", 5
+
+	custom := func(name, value string) agilecrm.Property {
+		return agilecrm.Property{Name: name, Type: agilecrm.TypeCustom, Value: value}
+	}
+
+	tests := []struct {
+		name  string
+		props agilecrm.PropertyList
+		field string
+		want  interface{}
+	}{
+		{
+			name: "subtype",
+			props: agilecrm.PropertyList{
+				{Name: "email", Subtype: "home", Value: "home@example.com"},
+				{Name: "email", Subtype: "work", Value: "work@example.com"},
+			},
+			field: "Email",
+			want:  "work@example.com",
+		},
+		{name: "string", props: agilecrm.PropertyList{custom("Plan", "Gold")}, field: "Plan", want: "Gold"},
+		{name: "name ignores case", props: agilecrm.PropertyList{custom("plan", "Gold")}, field: "Plan", want: "Gold"},
+		{name: "embedded", props: agilecrm.PropertyList{custom("Source", "ads")}, field: "Source", want: "ads"},
+		{name: "int", props: agilecrm.PropertyList{custom("Seats", " 12 ")}, field: "Seats", want: 12},
+		{name: "empty int", props: agilecrm.PropertyList{custom("Seats", "")}, field: "Seats", want: 0},
+		{name: "float", props: agilecrm.PropertyList{custom("Ratio", "0.5")}, field: "Ratio", want: 0.5},
+		{name: "uint", props: agilecrm.PropertyList{custom("Count", "7")}, field: "Count", want: uint(7)},
+		{name: "bool on", props: agilecrm.PropertyList{custom("Trial", "on")}, field: "Trial", want: true},
+		{name: "bool yes", props: agilecrm.PropertyList{custom("Trial", "YES")}, field: "Trial", want: true},
+		{name: "bool true", props: agilecrm.PropertyList{custom("Trial", "true")}, field: "Trial", want: true},
+		{name: "bool off", props: agilecrm.PropertyList{custom("Trial", "off")}, field: "Trial", want: false},
+		{name: "bool empty", props: agilecrm.PropertyList{custom("Trial", "")}, field: "Trial", want: false},
+		{name: "date seconds", props: agilecrm.PropertyList{custom("Renewal Date", "1704153600")}, field: "Renewal", want: day},
+		{name: "date millis", props: agilecrm.PropertyList{custom("Renewal Date", "1704153600000")}, field: "Renewal", want: day},
+		{name: "date RFC 3339", props: agilecrm.PropertyList{custom("Renewal Date", "2024-01-02T00:00:00Z")}, field: "Renewal", want: day},
+		{name: "date", props: agilecrm.PropertyList{custom("Renewal Date", "2024-01-02")}, field: "Renewal", want: day},
+		{name: "US date", props: agilecrm.PropertyList{custom("Renewal Date", "01/02/2024")}, field: "Renewal", want: day},
+		{name: "empty date", props: agilecrm.PropertyList{custom("Renewal Date", "")}, field: "Renewal", want: time.Time{}},
+		{name: "Time", props: agilecrm.PropertyList{custom("Signed", "2024-01-02")}, field: "Signed", want: agilecrm.NewTime(day)},
+		{name: "MilliTime date", props: agilecrm.PropertyList{custom("Seen", "2024-01-02")}, field: "Seen", want: agilecrm.NewMilliTime(day)},
+		{name: "MilliTime millis", props: agilecrm.PropertyList{custom("Seen", "1704153600123")}, field: "Seen", want: agilecrm.MilliTime(1704153600123)},
+		{name: "MilliTime seconds", props: agilecrm.PropertyList{custom("Seen", "1704153600")}, field: "Seen", want: agilecrm.NewMilliTime(day)},
+		{name: "list", props: agilecrm.PropertyList{custom("Products", "a, b,,c")}, field: "Products", want: []string{"a", "b", "c"}},
+		{
+			name:  "address",
+			props: agilecrm.PropertyList{{Name: "address", Value: `{"city":"Springfield","zip":"12345"}`}},
+			field: "Address",
+			want:  agilecrm.Address{City: "Springfield", Zip: "12345"},
+		},
+		{name: "pointer", props: agilecrm.PropertyList{custom("Manager", "Ann")}, field: "Manager", want: &ann},
+		{name: "empty pointer", props: agilecrm.PropertyList{custom("Manager", "")}, field: "Manager", want: (*string)(nil)},
+		{name: "int pointer", props: agilecrm.PropertyList{custom("Budget", "5")}, field: "Budget", want: &five},
+		{name: "ignored field", props: agilecrm.PropertyList{custom("-", "x"), custom("Skipped", "x")}, field: "Skipped", want: "kept"},
+		{name: "untagged field", props: agilecrm.PropertyList{custom("Untagged", "x")}, field: "Untagged", want: "kept"},
+		{name: "missing property", props: agilecrm.PropertyList{}, field: "Plan", want: "kept"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := lead{Plan: "kept", Skipped: "kept", Untagged: "kept"}
+			if err := (agilecrm.Contact{Properties: tt.props}).Decode(&l); err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+
+			got := reflect.ValueOf(l).FieldByName(tt.field).Interface()
+			if !bindEqual(got, tt.want) {
+				t.Errorf("%v = %#v, want %#v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
+
+// bindEqual compares field values, times by the instant they describe
+func bindEqual(got, want interface{}) bool {
+	if w, ok := want.(time.Time); ok {
+		g, ok := got.(time.Time)
+		return ok && g.Equal(w)
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		prop  string
+		value string
+	}{
+		{"int", "Seats", "Seats", "many"},
+		{"uint", "Count", "Count", "-1"},
+		{"float", "Ratio", "Ratio", "half"},
+		{"bool", "Trial", "Trial", "maybe"},
+		{"date", "Renewal", "Renewal Date", "yesterday"},
+		{"Time", "Signed", "Signed", "soon"},
+		{"MilliTime", "Seen", "Seen", "never"},
+		{"address", "Address", "address", "{"},
+		{"pointer", "Budget", "Budget", "lots"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := agilecrm.PropertyList{
+				{Name: tt.prop, Value: tt.value},
+				{Name: "Plan", Value: "Gold"},
+			}
+
+			l := lead{}
+			err := pl.Decode(&l)
+
+			var errs agilecrm.PropertyErrors
+			if !errors.As(err, &errs) || len(errs) != 1 {
+				t.Fatalf("error = %v, want one PropertyError", err)
+			}
+			if e := errs[0]; e.Field != tt.field || e.Property != tt.prop || e.Value != tt.value || e.Err == nil {
+				t.Errorf("error = %+v, want field %v property %v value %q", e, tt.field, tt.prop, tt.value)
+			}
+
+			// the other fields are still decoded
+			if l.Plan != "Gold" {
+				t.Errorf("plan = %q after a failed field", l.Plan)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorsCollected(t *testing.T) {
+	pl := agilecrm.PropertyList{
+		{Name: "Seats", Value: "many"},
+		{Name: "Trial", Value: "maybe"},
+	}
+
+	err := pl.Decode(&lead{})
+	var errs agilecrm.PropertyErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("error = %v, want both fields", err)
+	}
+	if errs[0].Field != "Seats" || errs[1].Field != "Trial" {
+		t.Errorf("fields = %v and %v, want Seats and Trial", errs[0].Field, errs[1].Field)
+	}
+}
+
+func TestBinderInvalidTargets(t *testing.T) {
+	type badOption struct {
+		Plan string `agile:"Plan,custom,sometimes"`
+	}
+	type noName struct {
+		Plan string `agile:",custom"`
+	}
+	type unexported struct {
+		plan string `agile:"Plan,custom"`
+	}
+	type unsupported struct {
+		Extra map[string]string `agile:"Extra,custom"`
+	}
+
+	pl := agilecrm.PropertyList{{Name: "Plan", Value: "Gold"}, {Name: "Extra", Value: "x"}}
+	s := ""
+
+	tests := []struct {
+		name      string
+		v         interface{}
+		fieldErr  string
+		encodeErr bool
+	}{
+		{name: "not a pointer", v: lead{}},
+		{name: "nil pointer", v: (*lead)(nil)},
+		{name: "not a struct", v: &s},
+		{name: "unknown tag option", v: &badOption{}, fieldErr: "Plan", encodeErr: true},
+		{name: "tag without a name", v: &noName{}, fieldErr: "Plan", encodeErr: true},
+		{name: "unexported field", v: &unexported{}, fieldErr: "plan", encodeErr: true},
+		{name: "unsupported type", v: &unsupported{Extra: map[string]string{"a": "b"}}, fieldErr: "Extra", encodeErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pl.Decode(tt.v)
+			if err == nil {
+				t.Fatal("expected decode to fail")
+			}
+
+			var errs agilecrm.PropertyErrors
+			isFieldErr := errors.As(err, &errs)
+			if tt.fieldErr == "" && isFieldErr {
+				t.Errorf("error = %v, want a plain error", err)
+			}
+			if tt.fieldErr != "" && (!isFieldErr || errs[0].Field != tt.fieldErr) {
+				t.Errorf("error = %v, want a PropertyError for %v", err, tt.fieldErr)
+			}
+
+			if tt.encodeErr {
+				if _, err := agilecrm.EncodeProperties(tt.v); !errors.As(err, &errs) {
+					t.Errorf("encode error = %v, want PropertyErrors", err)
+				}
+			}
+		})
+	}
+
+	if _, err := agilecrm.EncodeProperties("lead"); err == nil {
+		t.Error("expected encoding a string to fail")
+	}
+}
+
+func TestEncodeProperties(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	five := 5
+
+	in := lead{
+		leadSource: leadSource{Source: "ads"},
+		Email:      "work@example.com",
+		Plan:       "",
+		Seats:      12,
+		Ratio:      0.5,
+		Count:      7,
+		Trial:      true,
+		Renewal:    day,
+		Signed:     agilecrm.NewTime(day),
+		Seen:       agilecrm.MilliTime(1704153600123),
+		Address:    agilecrm.Address{City: "Springfield"},
+		Budget:     &five,
+		Skipped:    "skipped",
+		Untagged:   "untagged",
+	}
+
+	pl, err := agilecrm.EncodeProperties(&in)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	want := map[string]agilecrm.Property{
+		"Source":       {Name: "Source", Type: agilecrm.TypeCustom, Value: "ads"},
+		"email":        {Name: "email", Type: agilecrm.TypeSystem, Subtype: "work", Value: "work@example.com"},
+		"Plan":         {Name: "Plan", Type: agilecrm.TypeCustom, Value: ""},
+		"Seats":        {Name: "Seats", Type: agilecrm.TypeCustom, Value: "12"},
+		"Ratio":        {Name: "Ratio", Type: agilecrm.TypeCustom, Value: "0.5"},
+		"Count":        {Name: "Count", Type: agilecrm.TypeCustom, Value: "7"},
+		"Trial":        {Name: "Trial", Type: agilecrm.TypeCustom, Value: "on"},
+		"Renewal Date": {Name: "Renewal Date", Type: agilecrm.TypeCustom, Value: "1704153600"},
+		"Signed":       {Name: "Signed", Type: agilecrm.TypeCustom, Value: "1704153600"},
+		"Seen":         {Name: "Seen", Type: agilecrm.TypeCustom, Value: "1704153600123"},
+		"address":      {Name: "address", Type: agilecrm.TypeSystem, Value: `{"city":"Springfield"}`},
+		"Budget":       {Name: "Budget", Type: agilecrm.TypeCustom, Value: "5"},
+	}
+
+	// Products is empty and omitempty, Manager a nil pointer
+	if len(pl) != len(want) {
+		t.Errorf("got %v properties, want %v: %+v", len(pl), len(want), pl)
+	}
+	for _, p := range pl {
+		if w, ok := want[p.Name]; !ok || p != w {
+			t.Errorf("property %+v, want %+v", p, w)
+		}
+	}
+
+	out := lead{}
+	if err := pl.Decode(&out); err != nil {
+		t.Fatalf("unable to decode the encoded properties; %v", err)
+	}
+	in.Skipped, in.Untagged = "", ""
+	if !out.Renewal.Equal(in.Renewal) {
+		t.Errorf("renewal = %v, want %v", out.Renewal, in.Renewal)
+	}
+	out.Renewal = in.Renewal
+	if !reflect.DeepEqual(out, in) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestEncodeBool(t *testing.T) {
+	pl, err := agilecrm.EncodeProperties(struct {
+		Trial bool `agile:"Trial,custom"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pl) != 1 || pl[0].Value != "off" {
+		t.Errorf("false encoded as %+v, want off", pl)
+	}
+}
@@ -0,0 +1,196 @@
+package agilecrm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// boardPageSize is the page size used to load whole milestone columns
+const boardPageSize = 100
+
+// DealTotals sums up a set of deals
+type DealTotals struct {
+	Count int
+
+	// Value is the sum of the deals' expected values, and WeightedValue the
+	// same sum with each value weighted by the deal's probability
+	Value         float64
+	WeightedValue float64
+}
+
+// Add adds a deal to the totals
+func (t *DealTotals) Add(d Deal) {
+	t.Count++
+	t.Value += float64(d.ExpectedValue)
+	t.WeightedValue += float64(d.ExpectedValue) * float64(d.Probabilty) / 100
+}
+
+// Totals sums up the deals of the list
+func (dl DealList) Totals() DealTotals {
+	t := DealTotals{}
+	for _, d := range dl {
+		t.Add(d)
+	}
+	return t
+}
+
+// MilestoneColumn is the deals of a pipeline at one milestone
+type MilestoneColumn struct {
+	Milestone string
+	Deals     DealList
+
+	// Cursor fetches the column's next page with ListMilestoneDeals, and is
+	// empty on the last page
+	Cursor string
+
+	// Totals covers every deal of the milestone, including the ones past
+	// the loaded page
+	Totals DealTotals
+}
+
+// DealBoard is a pipeline's deals grouped by milestone, one column per
+// milestone in the pipeline's order
+type DealBoard struct {
+	Pipeline Pipeline
+	Columns  []MilestoneColumn
+}
+
+// Column returns the column of the milestone, ignoring case
+func (b *DealBoard) Column(milestone string) (*MilestoneColumn, bool) {
+	for i := range b.Columns {
+		if strings.EqualFold(b.Columns[i].Milestone, milestone) {
+			return &b.Columns[i], true
+		}
+	}
+	return nil, false
+}
+
+// Totals sums up every column
+func (b *DealBoard) Totals() DealTotals {
+	t := DealTotals{}
+	for _, col := range b.Columns {
+		t.Count += col.Totals.Count
+		t.Value += col.Totals.Value
+		t.WeightedValue += col.Totals.WeightedValue
+	}
+	return t
+}
+
+// ListDealsByMilestone groups the deals of a pipeline by milestone. Each
+// column holds the first perPage deals of its milestone along with the
+// cursor of the next page, and the rest of the milestone is still read to
+// compute the column's totals. A perPage of zero loads every deal of each
+// column instead.
+func (c *Client) ListDealsByMilestone(pipelineID int64, perPage int) (*DealBoard, error) {
+	return c.ListDealsByMilestoneContext(context.Background(), pipelineID, perPage)
+}
+
+// ListDealsByMilestoneContext ...
+func (c *Client) ListDealsByMilestoneContext(ctx context.Context, pipelineID int64, perPage int) (*DealBoard, error) {
+	p, err := c.GetPipelineContext(ctx, pipelineID)
+	if err != nil {
+		return nil, err
+	}
+	return c.dealBoard(ctx, *p, perPage)
+}
+
+// ListDefaultDealsByMilestone is ListDealsByMilestone for the account's
+// default pipeline
+func (c *Client) ListDefaultDealsByMilestone(perPage int) (*DealBoard, error) {
+	return c.ListDefaultDealsByMilestoneContext(context.Background(), perPage)
+}
+
+// ListDefaultDealsByMilestoneContext ...
+func (c *Client) ListDefaultDealsByMilestoneContext(ctx context.Context, perPage int) (*DealBoard, error) {
+	pl, err := c.ListPipelinesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := pl.Default()
+	if !ok {
+		return nil, fmt.Errorf("account has no pipelines")
+	}
+	return c.dealBoard(ctx, p, perPage)
+}
+
+// dealBoard ...
+func (c *Client) dealBoard(ctx context.Context, p Pipeline, perPage int) (*DealBoard, error) {
+	out := &DealBoard{Pipeline: p, Columns: []MilestoneColumn{}}
+	for _, m := range p.Milestones {
+		col := MilestoneColumn{Milestone: m, Deals: DealList{}}
+
+		if perPage > 0 {
+			dl, err := c.ListMilestoneDealsContext(ctx, int64(p.ID), m, perPage, "")
+			if err != nil {
+				return nil, err
+			}
+			col.Deals = dl
+			col.Cursor = dl.Cursor()
+
+			totals, err := c.milestoneTotals(ctx, int64(p.ID), m, dl, col.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			col.Totals = totals
+		} else {
+			it := c.IterFilterDeals(milestoneFilter(int64(p.ID), m).PageSize(boardPageSize))
+			for it.Next(ctx) {
+				col.Deals = append(col.Deals, *it.Deal())
+			}
+			if err := it.Err(); err != nil {
+				return nil, err
+			}
+			col.Totals = col.Deals.Totals()
+		}
+
+		out.Columns = append(out.Columns, col)
+	}
+	return out, nil
+}
+
+// milestoneTotals sums up the loaded page of a milestone and every page
+// after it, without keeping the deals that weren't loaded
+func (c *Client) milestoneTotals(ctx context.Context, pipelineID int64, milestone string, loaded DealList, cursor string) (DealTotals, error) {
+	t := loaded.Totals()
+	if cursor == "" {
+		return t, nil
+	}
+
+	it := c.IterFilterDeals(milestoneFilter(pipelineID, milestone).PageSize(boardPageSize).Cursor(cursor))
+	for it.Next(ctx) {
+		t.Add(*it.Deal())
+	}
+	return t, it.Err()
+}
+
+// ListMilestoneDeals returns a page of the deals of a pipeline at a
+// milestone
+func (c *Client) ListMilestoneDeals(pipelineID int64, milestone string, perPage int, cursor string) (DealList, error) {
+	return c.ListMilestoneDealsContext(context.Background(), pipelineID, milestone, perPage, cursor)
+}
+
+// ListMilestoneDealsContext ...
+func (c *Client) ListMilestoneDealsContext(ctx context.Context, pipelineID int64, milestone string, perPage int, cursor string) (DealList, error) {
+	q := milestoneFilter(pipelineID, milestone).PageSize(perPage).Cursor(cursor)
+	return c.FilterDealsContext(ctx, q)
+}
+
+// ListPipelineDeals returns a page of the deals of a pipeline
+func (c *Client) ListPipelineDeals(pipelineID int64, perPage int, cursor string) (DealList, error) {
+	return c.ListPipelineDealsContext(context.Background(), pipelineID, perPage, cursor)
+}
+
+// ListPipelineDealsContext ...
+func (c *Client) ListPipelineDealsContext(ctx context.Context, pipelineID int64, perPage int, cursor string) (DealList, error) {
+	q := Filter().Where("pipeline_id", FilterEqual, pipelineID).PageSize(perPage).Cursor(cursor)
+	return c.FilterDealsContext(ctx, q)
+}
+
+// milestoneFilter ...
+func milestoneFilter(pipelineID int64, milestone string) *FilterQuery {
+	return Filter().
+		Where("pipeline_id", FilterEqual, pipelineID).
+		Where("milestone", FilterEqual, milestone)
+}
@@ -0,0 +1,109 @@
+package agilecrm_test
+
+import (
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestDealTotals(t *testing.T) {
+	dl := agilecrm.DealList{
+		{ExpectedValue: 100, Probabilty: 50},
+		{ExpectedValue: 200, Probabilty: 10},
+		{ExpectedValue: 300},
+	}
+
+	got := dl.Totals()
+	want := agilecrm.DealTotals{Count: 3, Value: 600, WeightedValue: 70}
+	if got != want {
+		t.Errorf("totals = %+v, want %+v", got, want)
+	}
+
+	if got := (agilecrm.DealList{}).Totals(); got != (agilecrm.DealTotals{}) {
+		t.Errorf("empty list totals = %+v", got)
+	}
+}
+
+func TestListDealsByMilestone(t *testing.T) {
+	tests := []struct {
+		name    string
+		perPage int
+		loaded  []int
+		cursor  []bool
+	}{
+		{name: "paged", perPage: 2, loaded: []int{2, 0, 0, 1, 0}, cursor: []bool{true, false, false, false, false}},
+		{name: "whole columns", perPage: 0, loaded: []int{5, 0, 0, 1, 0}, cursor: []bool{false, false, false, false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+
+			for i := 1; i <= 5; i++ {
+				srv.AddDeal(agilecrm.Deal{Name: "new", PipelineID: agilecrmtest.DefaultPipelineID, Milestone: "New", ExpectedValue: agilecrm.Float(i * 100), Probabilty: 50})
+			}
+			srv.AddDeal(agilecrm.Deal{Name: "won", PipelineID: agilecrmtest.DefaultPipelineID, Milestone: "Won", ExpectedValue: 1000, Probabilty: 100})
+			other := srv.AddPipeline(agilecrm.Pipeline{Name: "Other", Milestones: agilecrm.Milestones{"New"}})
+			srv.AddDeal(agilecrm.Deal{Name: "other", PipelineID: other.ID, Milestone: "New", ExpectedValue: 5000})
+
+			board, err := cl.ListDealsByMilestone(int64(agilecrmtest.DefaultPipelineID), tt.perPage)
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+			if len(board.Columns) != len(tt.loaded) {
+				t.Fatalf("%v columns, want %v", len(board.Columns), len(tt.loaded))
+			}
+			for i, col := range board.Columns {
+				if len(col.Deals) != tt.loaded[i] || (col.Cursor != "") != tt.cursor[i] {
+					t.Errorf("%v: %v deals with cursor %q, want %v", col.Milestone, len(col.Deals), col.Cursor, tt.loaded[i])
+				}
+			}
+
+			// the totals cover the whole milestone, whatever was loaded
+			col, ok := board.Column("new")
+			if !ok {
+				t.Fatal("no column for New")
+			}
+			if want := (agilecrm.DealTotals{Count: 5, Value: 1500, WeightedValue: 750}); col.Totals != want {
+				t.Errorf("New totals = %+v, want %+v", col.Totals, want)
+			}
+			if want := (agilecrm.DealTotals{Count: 6, Value: 2500, WeightedValue: 1750}); board.Totals() != want {
+				t.Errorf("board totals = %+v, want %+v", board.Totals(), want)
+			}
+
+			// the cursor resumes the column where the board stopped
+			if col.Cursor != "" {
+				next, err := cl.ListMilestoneDeals(int64(agilecrmtest.DefaultPipelineID), "New", 10, col.Cursor)
+				if err != nil {
+					t.Fatalf("unexpected error; %v", err)
+				}
+				if len(next) != 3 {
+					t.Errorf("next page has %v deals, want 3", len(next))
+				}
+			}
+		})
+	}
+}
+
+func TestListDefaultDealsByMilestone(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	srv.AddDeal(agilecrm.Deal{Name: "lost", PipelineID: agilecrmtest.DefaultPipelineID, Milestone: "Lost", ExpectedValue: 10})
+
+	board, err := cl.ListDefaultDealsByMilestone(1)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if board.Pipeline.ID != agilecrmtest.DefaultPipelineID {
+		t.Errorf("board of pipeline %v, want the default one", board.Pipeline.ID)
+	}
+	if col, ok := board.Column("Lost"); !ok || len(col.Deals) != 1 || col.Totals.Value != 10 {
+		t.Errorf("Lost column = %+v", col)
+	}
+	if _, ok := board.Column("Missing"); ok {
+		t.Error("found a column for a milestone the pipeline doesn't have")
+	}
+}
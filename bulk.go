@@ -0,0 +1,280 @@
+package agilecrm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// bulkConcurrency is how many deals are changed at once when a bulk endpoint
+// isn't available and each deal is changed on its own
+const bulkConcurrency = 8
+
+// BulkResult is the outcome of a bulk operation for one deal
+type BulkResult struct {
+	ID  int64
+	Err error
+}
+
+// BulkReport lists the outcome of a bulk operation for each deal, in the
+// order the IDs were given
+type BulkReport struct {
+	// Bulk tells whether the API's bulk endpoint made the change, rather than
+	// one request per deal. Its answer doesn't say which deals were changed,
+	// so every deal is reported as changed unless Config.VerifyBulk is set.
+	Bulk bool
+
+	Results []BulkResult
+}
+
+// Failed returns the results with an error
+func (r *BulkReport) Failed() []BulkResult {
+	out := []BulkResult{}
+	for _, res := range r.Results {
+		if res.Err != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Succeeded returns the IDs of the deals that were changed
+func (r *BulkReport) Succeeded() []int64 {
+	out := []int64{}
+	for _, res := range r.Results {
+		if res.Err == nil {
+			out = append(out, res.ID)
+		}
+	}
+	return out
+}
+
+// Err summarizes the failures, wrapping the first one, or returns nil when
+// every deal was changed
+func (r *BulkReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%v of %v deals failed, first %v: %w", len(failed), len(r.Results), failed[0].ID, failed[0].Err)
+}
+
+// bulkOp is an operation the bulk routes can make on many deals at once
+type bulkOp struct {
+	route  string
+	params url.Values
+
+	// each makes the change to one deal, when the bulk route can't be used
+	each func(ctx context.Context, id int64) error
+
+	// check tells whether the bulk route made the change to a deal, and is
+	// only used with Config.VerifyBulk
+	check func(ctx context.Context, id int64) error
+}
+
+// BulkDeleteDeals deletes the deals. The API's bulk endpoint is used when the
+// account has it, otherwise each deal is deleted on its own, a few at a time.
+// Failures don't stop the other deletions; they are listed in the report, and
+// the returned error is the report's Err.
+//
+// When the bulk endpoint fails with a server error, the deals are deleted on
+// their own as well. It may have deleted some before failing, so a deal that
+// doesn't exist counts as deleted.
+func (c *Client) BulkDeleteDeals(ids []int64) (*BulkReport, error) {
+	return c.BulkDeleteDealsContext(context.Background(), ids)
+}
+
+// BulkDeleteDealsContext ...
+func (c *Client) BulkDeleteDealsContext(ctx context.Context, ids []int64) (*BulkReport, error) {
+	return c.bulk(ctx, ids, bulkOp{
+		route: "api/opportunity/bulk",
+		each: func(ctx context.Context, id int64) error {
+			err := c.DeleteDealContext(ctx, int(id))
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				return nil
+			}
+			return err
+		},
+		check: func(ctx context.Context, id int64) error {
+			_, err := c.FindDealByIDContext(ctx, int(id))
+			if errors.Is(err, ErrNoSuchContact) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("deal %v still exists after the bulk delete", id)
+		},
+	})
+}
+
+// BulkChangeDealOwner gives the deals to another user, the same way
+// BulkDeleteDeals deletes them
+func (c *Client) BulkChangeDealOwner(ids []int64, ownerID int64) (*BulkReport, error) {
+	return c.BulkChangeDealOwnerContext(context.Background(), ids, ownerID)
+}
+
+// BulkChangeDealOwnerContext ...
+func (c *Client) BulkChangeDealOwnerContext(ctx context.Context, ids []int64, ownerID int64) (*BulkReport, error) {
+	if ownerID <= 0 {
+		return nil, fmt.Errorf("owner ID is required to change the owner of deals")
+	}
+
+	return c.bulk(ctx, ids, bulkOp{
+		route: fmt.Sprintf("api/opportunity/bulk/change-owner/%v", ownerID),
+		each: func(ctx context.Context, id int64) error {
+			return c.patchDeal(ctx, id, Deal{OwnerID: ID(ownerID)})
+		},
+		check: func(ctx context.Context, id int64) error {
+			d, err := c.FindDealByIDContext(ctx, int(id))
+			if err != nil {
+				return err
+			}
+			if int64(d.OwnerID) != ownerID {
+				return fmt.Errorf("deal %v is still owned by %v after the bulk change", id, d.OwnerID)
+			}
+			return nil
+		},
+	})
+}
+
+// BulkMoveDeals moves the deals to a milestone of a pipeline, the same way
+// BulkDeleteDeals deletes them. With ValidateMilestones set, the milestone
+// is checked once before any deal is moved.
+func (c *Client) BulkMoveDeals(ids []int64, pipelineID int64, milestone string) (*BulkReport, error) {
+	return c.BulkMoveDealsContext(context.Background(), ids, pipelineID, milestone)
+}
+
+// BulkMoveDealsContext ...
+func (c *Client) BulkMoveDealsContext(ctx context.Context, ids []int64, pipelineID int64, milestone string) (*BulkReport, error) {
+	if pipelineID <= 0 || milestone == "" {
+		return nil, fmt.Errorf("pipeline ID and milestone are required to move deals")
+	}
+
+	move := Deal{PipelineID: ID(pipelineID), Milestone: milestone}
+	if c.validateMilestones {
+		if err := c.ValidateDealMilestoneContext(ctx, move); err != nil {
+			return nil, err
+		}
+	}
+
+	params := url.Values{}
+	params.Add("pipeline_id", fmt.Sprintf("%v", pipelineID))
+	params.Add("milestone", milestone)
+	return c.bulk(ctx, ids, bulkOp{
+		route:  "api/opportunity/bulk/change-milestone",
+		params: params,
+		each: func(ctx context.Context, id int64) error {
+			return c.patchDeal(ctx, id, move)
+		},
+		check: func(ctx context.Context, id int64) error {
+			d, err := c.FindDealByIDContext(ctx, int(id))
+			if err != nil {
+				return err
+			}
+			if d.PipelineID != move.PipelineID || !strings.EqualFold(d.Milestone, milestone) {
+				return fmt.Errorf("deal %v is still at %q of pipeline %v after the bulk move", id, d.Milestone, d.PipelineID)
+			}
+			return nil
+		},
+	})
+}
+
+// bulk runs an operation over the deals through its bulk route, reading the
+// deals back to tell which were changed only when verifyBulk is set. It
+// falls back to changing each deal on its own when the route isn't available
+// or fails with a server error; other failures, like bad credentials, fail
+// every deal.
+func (c *Client) bulk(ctx context.Context, ids []int64, op bulkOp) (*BulkReport, error) {
+	// every deal is changed once, whatever the number of times it was given
+	rep := &BulkReport{Results: []BulkResult{}}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			rep.Results = append(rep.Results, BulkResult{ID: id})
+		}
+	}
+
+	pending := []*BulkResult{}
+	for i := range rep.Results {
+		pending = append(pending, &rep.Results[i])
+	}
+	if len(pending) == 0 {
+		return rep, nil
+	}
+
+	err := c.bulkRequest(ctx, op.route, pending, op.params)
+
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		rep.Bulk = true
+		if c.verifyBulk {
+			c.bulkEach(ctx, pending, op.check)
+		}
+
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound ||
+		apiErr.StatusCode == http.StatusMethodNotAllowed ||
+		apiErr.StatusCode >= http.StatusInternalServerError):
+		c.bulkEach(ctx, pending, op.each)
+
+	default:
+		for _, res := range pending {
+			res.Err = err
+		}
+	}
+	return rep, rep.Err()
+}
+
+// bulkRequest posts the IDs to a bulk route, along with the other params
+func (c *Client) bulkRequest(ctx context.Context, route string, results []*BulkResult, params url.Values) error {
+	ids := []string{}
+	for _, res := range results {
+		ids = append(ids, ID(res.ID).String())
+	}
+	bits, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+
+	vals := url.Values{}
+	for k, v := range params {
+		vals[k] = v
+	}
+	vals.Set("ids", string(bits))
+
+	req, err := c.postForm(ctx, "POST", route, strings.NewReader(vals.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	// the body, if any, isn't needed
+	_, err = c.processResults(req, &json.RawMessage{})
+	return err
+}
+
+// bulkEach calls each on every deal, bulkConcurrency at a time, recording
+// the results
+func (c *Client) bulkEach(ctx context.Context, results []*BulkResult, each func(context.Context, int64) error) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, bulkConcurrency)
+	)
+	for _, res := range results {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(res *BulkResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res.Err = each(ctx, res.ID)
+		}(res)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,224 @@
+package agilecrm_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestBulkDeals(t *testing.T) {
+	const missing = 424242
+
+	ops := []struct {
+		name string
+		run  func(cl *agilecrm.Client, ids []int64) (*agilecrm.BulkReport, error)
+
+		// deletes is set for the delete, which counts a missing deal as
+		// deleted
+		deletes bool
+
+		// changed tells whether the stored deal, nil when deleted, shows the
+		// operation
+		changed func(d *agilecrm.Deal) bool
+	}{
+		{
+			name:    "delete",
+			run:     (*agilecrm.Client).BulkDeleteDeals,
+			deletes: true,
+			changed: func(d *agilecrm.Deal) bool {
+				return d == nil
+			},
+		},
+		{
+			name: "change owner",
+			run: func(cl *agilecrm.Client, ids []int64) (*agilecrm.BulkReport, error) {
+				return cl.BulkChangeDealOwner(ids, 77)
+			},
+			changed: func(d *agilecrm.Deal) bool {
+				return d != nil && d.OwnerID == 77
+			},
+		},
+		{
+			name: "move",
+			run: func(cl *agilecrm.Client, ids []int64) (*agilecrm.BulkReport, error) {
+				return cl.BulkMoveDeals(ids, int64(agilecrmtest.DefaultPipelineID), "Won")
+			},
+			changed: func(d *agilecrm.Deal) bool {
+				return d != nil && d.Milestone == "Won"
+			},
+		},
+	}
+
+	modes := []struct {
+		name   string
+		verify bool
+		fault  *agilecrmtest.Fault
+
+		// bulk is whether the bulk route is expected to make the change,
+		// applied whether the deals are changed at all, and perDeal whether
+		// each deal is then requested on its own
+		bulk    bool
+		applied bool
+		perDeal bool
+	}{
+		{name: "bulk route", bulk: true, applied: true},
+		{name: "bulk route verified", verify: true, bulk: true, applied: true, perDeal: true},
+		{
+			name:    "route missing",
+			fault:   &agilecrmtest.Fault{Route: "api/opportunity/bulk", Status: http.StatusNotFound},
+			applied: true,
+			perDeal: true,
+		},
+		{
+			name:    "server error",
+			fault:   &agilecrmtest.Fault{Route: "api/opportunity/bulk", Status: http.StatusInternalServerError},
+			applied: true,
+			perDeal: true,
+		},
+		{
+			name:  "rejected",
+			fault: &agilecrmtest.Fault{Route: "api/opportunity/bulk", Status: http.StatusBadRequest},
+		},
+	}
+
+	for _, op := range ops {
+		for _, mode := range modes {
+			t.Run(op.name+"/"+mode.name, func(t *testing.T) {
+				srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.VerifyBulk = mode.verify })
+				defer srv.Close()
+
+				ids := []int64{}
+				for _, name := range []string{"a", "b"} {
+					d, err := cl.CreateDeal(agilecrm.Deal{Name: name, Milestone: "New"})
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, int64(d.ID))
+				}
+				if mode.fault != nil {
+					srv.Inject(*mode.fault)
+				}
+
+				// duplicates are changed once, and unknown deals only fail
+				// when each deal is requested, unless they are deleted
+				before := srv.Requests()
+				rep, err := op.run(cl, []int64{ids[0], ids[1], missing, ids[0]})
+				missingFails := !mode.applied || (mode.perDeal && !op.deletes)
+				if (err != nil) != missingFails {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if rep.Bulk != mode.bulk {
+					t.Errorf("bulk = %v, want %v", rep.Bulk, mode.bulk)
+				}
+				if len(rep.Results) != 3 {
+					t.Fatalf("got %v results, want 3", len(rep.Results))
+				}
+
+				failed := map[int64]bool{}
+				for _, res := range rep.Failed() {
+					failed[res.ID] = true
+				}
+				if failed[missing] != missingFails {
+					t.Errorf("unknown deal %v failed = %v, want %v", missing, failed[missing], missingFails)
+				}
+				for _, id := range ids {
+					if failed[id] == mode.applied {
+						t.Errorf("deal %v failed = %v, want %v", id, failed[id], !mode.applied)
+					}
+
+					var stored *agilecrm.Deal
+					if d, ok := srv.Deal(agilecrm.ID(id)); ok {
+						stored = &d
+					}
+					if op.changed(stored) != mode.applied {
+						t.Errorf("deal %v changed = %v, want %v", id, !mode.applied, mode.applied)
+					}
+				}
+
+				// the bulk call, then one request per deal when needed
+				want := 1
+				if mode.perDeal {
+					want += len(rep.Results)
+				}
+				if n := srv.Requests() - before; n != want {
+					t.Errorf("made %v requests, want %v", n, want)
+				}
+			})
+		}
+	}
+}
+
+func TestBulkDeleteDealsPartialFailure(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	ids := []int64{}
+	for _, name := range []string{"a", "b", "c"} {
+		d := srv.AddDeal(agilecrm.Deal{Name: name, Milestone: "New"})
+		ids = append(ids, int64(d.ID))
+	}
+
+	// the bulk call deletes the first two deals before failing
+	srv.Inject(agilecrmtest.Fault{
+		Method: "POST",
+		Route:  "api/opportunity/bulk",
+		Status: http.StatusInternalServerError,
+		Times:  1,
+		Before: func() {
+			srv.RemoveDeal(agilecrm.ID(ids[0]))
+			srv.RemoveDeal(agilecrm.ID(ids[1]))
+		},
+	})
+
+	rep, err := cl.BulkDeleteDeals(ids)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if rep.Bulk {
+		t.Error("failed bulk call reported as making the change")
+	}
+	if got := rep.Succeeded(); len(got) != 3 {
+		t.Errorf("deleted %v, want every deal", got)
+	}
+	if n := len(srv.Deals()); n != 0 {
+		t.Errorf("%v deals left", n)
+	}
+}
+
+func TestBulkDealsArguments(t *testing.T) {
+	srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.ValidateMilestones = true })
+	defer srv.Close()
+
+	d, err := cl.CreateDeal(agilecrm.Deal{Name: "a", Milestone: "New"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int64{int64(d.ID)}
+
+	tests := []struct {
+		name string
+		run  func() (*agilecrm.BulkReport, error)
+	}{
+		{"no owner", func() (*agilecrm.BulkReport, error) { return cl.BulkChangeDealOwner(ids, 0) }},
+		{"no milestone", func() (*agilecrm.BulkReport, error) { return cl.BulkMoveDeals(ids, 1, "") }},
+		{"unknown milestone", func() (*agilecrm.BulkReport, error) { return cl.BulkMoveDeals(ids, 1, "Nowhere") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := srv.Requests()
+			if _, err := tt.run(); err == nil {
+				t.Fatal("expected an error")
+			}
+			if _, ok := srv.Deal(d.ID); !ok {
+				t.Fatal("deal was removed")
+			}
+			// only the pipeline may be looked up
+			if n := srv.Requests() - before; n > 1 {
+				t.Errorf("%v requests made before failing", n)
+			}
+		})
+	}
+}
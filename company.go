@@ -1,23 +1,46 @@
 package agilecrm
 
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
 // ListCompanies ...
 func (c *Client) ListCompanies() (ContactList, error) {
+	return c.ListCompaniesContext(context.Background())
+}
+
+// ListCompaniesContext ...
+func (c *Client) ListCompaniesContext(ctx context.Context) (ContactList, error) {
 	out := ContactList{}
-	_, err := c.get("POST", "api/contacts/companies/list", nil, nil, &out)
+	_, err := c.get(idempotent(ctx), "POST", "api/contacts/companies/list", nil, nil, &out)
 
 	return out, err
 }
 
 // CreateCompany ...
 func (c *Client) CreateCompany(in Contact) (*Contact, error) {
+	return c.CreateCompanyContext(context.Background(), in)
+}
+
+// CreateCompanyContext ...
+func (c *Client) CreateCompanyContext(ctx context.Context, in Contact) (*Contact, error) {
 	in.Type = TypeCompany
-	return c.createContact(in)
+	return c.createContact(ctx, in)
 }
 
 // UpdateCompany ...
 func (c *Client) UpdateCompanyProperties(id int64, in Contact) (*Contact, error) {
-	in.ID = id
-	return c._updateContact(in, "api/contacts/edit-properties")
+	return c.UpdateCompanyPropertiesContext(context.Background(), id, in)
+}
+
+// UpdateCompanyPropertiesContext ...
+func (c *Client) UpdateCompanyPropertiesContext(ctx context.Context, id int64, in Contact) (*Contact, error) {
+	in.ID = ID(id)
+	return c._updateContact(ctx, in, "api/contacts/edit-properties")
 }
 
 // FindCompanyById ...
@@ -25,7 +48,157 @@ func (c *Client) FindCompanyById(id int) (*Contact, error) {
 	return c.FindContactById(id)
 }
 
+// FindCompanyByIdContext ...
+func (c *Client) FindCompanyByIdContext(ctx context.Context, id int) (*Contact, error) {
+	return c.FindContactByIdContext(ctx, id)
+}
+
 // DeleteCompany ...
 func (c *Client) DeleteCompany(id int) error {
 	return c.DeleteContact(id)
 }
+
+// DeleteCompanyContext ...
+func (c *Client) DeleteCompanyContext(ctx context.Context, id int) error {
+	return c.DeleteContactContext(ctx, id)
+}
+
+// ListCompaniesPage ...
+func (c *Client) ListCompaniesPage(perPage int, cursor string) (ContactList, error) {
+	return c.ListCompaniesPageContext(context.Background(), perPage, cursor)
+}
+
+// ListCompaniesPageContext ...
+func (c *Client) ListCompaniesPageContext(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+	v := url.Values{}
+	if perPage > 0 {
+		v.Add("page_size", fmt.Sprintf("%v", perPage))
+	}
+	if cursor != "" {
+		v.Add("cursor", cursor)
+	}
+	v.Add("global_sort_key", "-created_time")
+
+	req, err := c.postForm(idempotent(ctx), "POST", "api/contacts/companies/list", strings.NewReader(v.Encode()), nil)
+	if err != nil {
+		return ContactList{}, err
+	}
+
+	out := ContactList{}
+	st, err := c.processResults(req, &out)
+	if err != nil {
+		return ContactList{}, err
+	}
+
+	if st == http.StatusNoContent {
+		return ContactList{}, statusError("POST", "api/contacts/companies/list", st, ErrNoContacts)
+	}
+
+	return out, nil
+}
+
+// AttachContactToCompany links a person to a company, replacing any company
+// it was linked to before. The person's company property is set to the
+// company's name, the way the AgileCRM UI links them.
+func (c *Client) AttachContactToCompany(contactID, companyID int64) (*Contact, error) {
+	return c.AttachContactToCompanyContext(context.Background(), contactID, companyID)
+}
+
+// AttachContactToCompanyContext ...
+func (c *Client) AttachContactToCompanyContext(ctx context.Context, contactID, companyID int64) (*Contact, error) {
+	company, err := c.FindCompanyByIdContext(ctx, int(companyID))
+	if err != nil {
+		return nil, err
+	}
+	if company.Type != TypeCompany {
+		return nil, fmt.Errorf("contact %v is not a company", companyID)
+	}
+
+	return c.setContactCompany(ctx, contactID, ID(companyID).String(), company.Properties.Value(PropName, ""))
+}
+
+// DetachContactFromCompany unlinks a person from its company and clears its
+// company property
+func (c *Client) DetachContactFromCompany(contactID int64) (*Contact, error) {
+	return c.DetachContactFromCompanyContext(context.Background(), contactID)
+}
+
+// DetachContactFromCompanyContext ...
+func (c *Client) DetachContactFromCompanyContext(ctx context.Context, contactID int64) (*Contact, error) {
+	return c.setContactCompany(ctx, contactID, "", "")
+}
+
+// setContactCompany updates a person's company link. It is sent as a map
+// since detaching sends an empty company id, which Contact would leave out.
+func (c *Client) setContactCompany(ctx context.Context, contactID int64, companyID, name string) (*Contact, error) {
+	in := map[string]interface{}{
+		"id":                 contactID,
+		"contact_company_id": companyID,
+		"properties": []map[string]string{
+			{"name": PropCompany, "type": TypeSystem, "value": name},
+		},
+	}
+
+	out := &Contact{}
+	_, err := c.send(ctx, "PUT", "api/contacts/edit-properties", nil, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListCompanyContacts returns a page of the people linked to a company
+func (c *Client) ListCompanyContacts(companyID int64, perPage int, cursor string) (ContactList, error) {
+	return c.ListCompanyContactsContext(context.Background(), companyID, perPage, cursor)
+}
+
+// ListCompanyContactsContext ...
+func (c *Client) ListCompanyContactsContext(ctx context.Context, companyID int64, perPage int, cursor string) (ContactList, error) {
+	r := fmt.Sprintf("api/contacts/related/%v", companyID)
+
+	params := map[string]string{}
+	if perPage > 0 {
+		params["page_size"] = fmt.Sprintf("%v", perPage)
+	}
+	if cursor != "" {
+		params["cursor"] = cursor
+	}
+
+	out := ContactList{}
+	st, err := c.get(ctx, "GET", r, nil, params, &out)
+	if err != nil {
+		return ContactList{}, err
+	}
+
+	if st == http.StatusNoContent {
+		return ContactList{}, statusError("GET", r, st, ErrNoContacts)
+	}
+
+	return out, nil
+}
+
+// Company returns the company the person is linked to, looked up through
+// the client, since a Contact holds only the company's ID. It is nil when
+// the person isn't linked to any.
+func (ctc Contact) Company(cl *Client) (*Contact, error) {
+	return cl.ContactCompany(ctc)
+}
+
+// CompanyContext ...
+func (ctc Contact) CompanyContext(ctx context.Context, cl *Client) (*Contact, error) {
+	return cl.ContactCompanyContext(ctx, ctc)
+}
+
+// ContactCompany returns the company a person is linked to. It is nil when
+// the person isn't linked to any.
+func (c *Client) ContactCompany(ctc Contact) (*Contact, error) {
+	return c.ContactCompanyContext(context.Background(), ctc)
+}
+
+// ContactCompanyContext ...
+func (c *Client) ContactCompanyContext(ctx context.Context, ctc Contact) (*Contact, error) {
+	if ctc.ContactCompanyId == 0 {
+		return nil, nil
+	}
+	return c.FindCompanyByIdContext(ctx, int(ctc.ContactCompanyId))
+}
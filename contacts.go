@@ -1,21 +1,30 @@
 package agilecrm
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
+	"sync"
 )
 
+// ContactType is the kind of record a contact, search or filter refers to
+type ContactType string
+
 const (
-	TypeContact = "PERSON"
-	TypeCompany = "COMPANY"
+	TypeContact ContactType = "PERSON"
+	TypeCompany ContactType = "COMPANY"
+
+	// TypeDeal only applies to searches and filters, which can also look
+	// for deals (opportunities)
+	TypeDeal ContactType = "opportunity"
 )
 
 // ContactUser ...
 type ContactUser struct {
-	ID          int64  `json:"id"`
+	ID          ID     `json:"id"`
 	Domain      string `json:"domain"`
 	Email       string `json:"email"`
 	Name        string `json:"name"`
@@ -26,25 +35,25 @@ type ContactUser struct {
 }
 
 type Viewed struct {
-	ViewedTime int   `json:"viewed_time,omitempty"`
-	ViewerId   int64 `json:"viewer_id,omitempty"`
+	ViewedTime MilliTime `json:"viewed_time,omitempty"`
+	ViewerId   ID        `json:"viewer_id,omitempty"`
 }
 
 // Contact ...
 type Contact struct {
-	ID                  int64  `json:"id,omitempty"`
-	Type                string `json:"type,omitempty"`
-	StarValue           int    `json:"star_value,omitempty"`
-	LeadScore           int    `json:"lead_score,omitempty"`
-	EntityType          string `json:"entity_type,omitempty"`
-	ContactCompanyId    string `json:"contact_company_id,omitempty"`
-	FormId              int64  `json:"formId,omitempty"`
-	LastContacted       int    `json:"last_contacted,omitempty"`
-	LastEmailed         int    `json:"last_emailed,omitempty"`
-	LastCampaignEmailed int    `json:"last_campaign_emailed,omitempty"`
-	LastCalled          int    `json:"last_called,omitempty"`
-	CreatedAt           int    `json:"created_time,omitempty"`
-	UpdatedAt           int    `json:"updated_time,omitempty"`
+	ID                  ID          `json:"id,omitempty"`
+	Type                ContactType `json:"type,omitempty"`
+	StarValue           Int         `json:"star_value,omitempty"`
+	LeadScore           Int         `json:"lead_score,omitempty"`
+	EntityType          string      `json:"entity_type,omitempty"`
+	ContactCompanyId    ID          `json:"contact_company_id,omitempty"`
+	FormId              ID          `json:"formId,omitempty"`
+	LastContacted       Time        `json:"last_contacted,omitempty"`
+	LastEmailed         Time        `json:"last_emailed,omitempty"`
+	LastCampaignEmailed Time        `json:"last_campaign_emailed,omitempty"`
+	LastCalled          Time        `json:"last_called,omitempty"`
+	CreatedAt           Time        `json:"created_time,omitempty"`
+	UpdatedAt           Time        `json:"updated_time,omitempty"`
 
 	Viewed *Viewed `json:"viewed,omitempty"`
 
@@ -52,8 +61,8 @@ type Contact struct {
 
 	TagsWithTime []struct {
 		Tag            string    `json:"tag,omitempty"`
-		CreatedAt      time.Time `json:"created_at,omitempty"`
-		AvailableCount int       `json:"available_count,omitempty"`
+		CreatedAt      MilliTime `json:"created_at,omitempty"`
+		AvailableCount Int       `json:"available_count,omitempty"`
 		EntityType     string    `json:"entity_type,omitempty"`
 	} `json:"tags_with_time,omitempty"`
 
@@ -70,14 +79,143 @@ type Contact struct {
 
 type ContactList []*Contact
 
-// Email ...
+// Email returns the contact's first email address, whatever its subtype
 func (c Contact) Email() string {
-	for _, p := range c.Properties {
-		if strings.ToLower(p.Name) == "email" {
-			return p.Value
-		}
+	return c.Properties.Value(PropEmail, "")
+}
+
+// Emails returns every email property of the contact
+func (c Contact) Emails() PropertyList {
+	return c.Properties.All(PropEmail)
+}
+
+// EmailFor returns the email address with the subtype (work, home,
+// personal), or an empty string
+func (c Contact) EmailFor(subtype string) string {
+	return c.Properties.subtypeValue(PropEmail, subtype)
+}
+
+// SetEmail replaces the email address with the subtype, adding it when the
+// contact has none
+func (c *Contact) SetEmail(subtype, email string) {
+	c.Properties.Set(Property{Name: PropEmail, Subtype: subtype, Value: email})
+}
+
+// AddEmail adds another email address to the contact
+func (c *Contact) AddEmail(subtype, email string) {
+	c.Properties.Add(Property{Name: PropEmail, Subtype: subtype, Value: email})
+}
+
+// Phone returns the contact's first phone number, whatever its subtype
+func (c Contact) Phone() string {
+	return c.Properties.Value(PropPhone, "")
+}
+
+// Phones returns every phone property of the contact
+func (c Contact) Phones() PropertyList {
+	return c.Properties.All(PropPhone)
+}
+
+// PhoneFor returns the phone number with the subtype (work, home, mobile,
+// main, home fax, work fax, other), or an empty string
+func (c Contact) PhoneFor(subtype string) string {
+	return c.Properties.subtypeValue(PropPhone, subtype)
+}
+
+// SetPhone replaces the phone number with the subtype, adding it when the
+// contact has none
+func (c *Contact) SetPhone(subtype, phone string) {
+	c.Properties.Set(Property{Name: PropPhone, Subtype: subtype, Value: phone})
+}
+
+// AddPhone adds another phone number to the contact
+func (c *Contact) AddPhone(subtype, phone string) {
+	c.Properties.Add(Property{Name: PropPhone, Subtype: subtype, Value: phone})
+}
+
+// Website returns the contact's first website, whatever its subtype
+func (c Contact) Website() string {
+	return c.Properties.Value(PropWebsite, "")
+}
+
+// Websites returns every website property of the contact
+func (c Contact) Websites() PropertyList {
+	return c.Properties.All(PropWebsite)
+}
+
+// SetWebsite replaces the website with the subtype, adding it when the
+// contact has none
+func (c *Contact) SetWebsite(subtype, url string) {
+	c.Properties.Set(Property{Name: PropWebsite, Subtype: subtype, Value: url})
+}
+
+// AddWebsite adds another website to the contact
+func (c *Contact) AddWebsite(subtype, url string) {
+	c.Properties.Add(Property{Name: PropWebsite, Subtype: subtype, Value: url})
+}
+
+// FirstName ...
+func (c Contact) FirstName() string {
+	return c.Properties.Value(PropFirstName, "")
+}
+
+// SetFirstName ...
+func (c *Contact) SetFirstName(v string) {
+	c.Properties.Set(Property{Name: PropFirstName, Value: v})
+}
+
+// LastName ...
+func (c Contact) LastName() string {
+	return c.Properties.Value(PropLastName, "")
+}
+
+// SetLastName ...
+func (c *Contact) SetLastName(v string) {
+	c.Properties.Set(Property{Name: PropLastName, Value: v})
+}
+
+// Name returns the name of a company, or the full name of a person
+func (c Contact) Name() string {
+	if c.Type == TypeCompany {
+		return c.Properties.Value(PropName, "")
 	}
-	return ""
+	return strings.TrimSpace(c.FirstName() + " " + c.LastName())
+}
+
+// SetName sets the name of a company
+func (c *Contact) SetName(v string) {
+	c.Properties.Set(Property{Name: PropName, Value: v})
+}
+
+// CompanyName returns the name of the company a person works for
+func (c Contact) CompanyName() string {
+	return c.Properties.Value(PropCompany, "")
+}
+
+// SetCompanyName ...
+func (c *Contact) SetCompanyName(v string) {
+	c.Properties.Set(Property{Name: PropCompany, Value: v})
+}
+
+// Title ...
+func (c Contact) Title() string {
+	return c.Properties.Value(PropTitle, "")
+}
+
+// SetTitle ...
+func (c *Contact) SetTitle(v string) {
+	c.Properties.Set(Property{Name: PropTitle, Value: v})
+}
+
+// AddressValue returns the raw value of the address property, which the API
+// stores as a json string
+func (c Contact) AddressValue() string {
+	return c.Properties.Value(PropAddress, "")
+}
+
+// SetAddressValue ...
+func (c *Contact) SetAddressValue(v string) {
+	c.Properties.Set(Property{Name: PropAddress, Value: v})
 }
 
 // Cursor ...
@@ -91,6 +229,11 @@ func (cl ContactList) Cursor() string {
 
 // ListContacts ...
 func (c *Client) ListContacts(perPage int, cursor string) (ContactList, error) {
+	return c.ListContactsContext(context.Background(), perPage, cursor)
+}
+
+// ListContactsContext ...
+func (c *Client) ListContactsContext(ctx context.Context, perPage int, cursor string) (ContactList, error) {
 	out := ContactList{}
 
 	params := map[string]string{}
@@ -101,176 +244,298 @@ func (c *Client) ListContacts(perPage int, cursor string) (ContactList, error) {
 		params["cursor"] = cursor
 	}
 
-	st, err := c.get("GET", "api/contacts", nil, params, &out)
-
-	if st == http.StatusOK && err == nil {
-		// everything's fine, just return
-		return out, nil
+	st, err := c.get(ctx, "GET", "api/contacts", nil, params, &out)
+	if err != nil {
+		return ContactList{}, err
 	}
 
-	// if the status code is one of the defined status from the API,
-	// return the proper error message
-	switch st {
-	case http.StatusNoContent:
-		return ContactList{}, ErrNoContacts
-	case http.StatusUnauthorized:
-		return ContactList{}, ErrUnauthorized
+	// an empty account is reported with a 204
+	if st == http.StatusNoContent {
+		return ContactList{}, statusError("GET", "api/contacts", st, ErrNoContacts)
 	}
 
-	return ContactList{}, err
+	return out, nil
 }
 
 // FindContactById ...
 func (c *Client) FindContactById(id int) (*Contact, error) {
+	return c.FindContactByIdContext(context.Background(), id)
+}
+
+// FindContactByIdContext ...
+func (c *Client) FindContactByIdContext(ctx context.Context, id int) (*Contact, error) {
 	r := fmt.Sprintf("api/contacts/%v", id)
 	out := Contact{}
-	err := c.findByID(r, &out)
+	err := c.findByID(ctx, r, &out)
 	if err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
 
-// FindContactByEmail ...
+// FindContactByEmail returns the contact with the email. When there is
+// none, the error wraps ErrNoSuchEmail.
 func (c *Client) FindContactByEmail(email string) (*Contact, error) {
+	return c.FindContactByEmailContext(context.Background(), email)
+}
+
+// FindContactByEmailContext ...
+func (c *Client) FindContactByEmailContext(ctx context.Context, email string) (*Contact, error) {
 	r := fmt.Sprintf("api/contacts/search/email/%v", email)
 
 	out := &Contact{}
-	st, err := c.get("GET", r, nil, nil, out)
-
-	if st == http.StatusOK && err == nil {
-		return out, nil
+	st, err := c.get(ctx, "GET", r, nil, nil, out)
+	if err != nil {
+		return nil, err
 	}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
+	if st == http.StatusNoContent {
+		return nil, statusError("GET", r, st, ErrNoSuchEmail)
 	}
 
-	return nil, err
+	return out, nil
 }
 
-// FindContactsByEmail ...
+// emailBatchSize is how many emails FindContactsByEmail looks up per request,
+// with at most emailBatchConcurrency requests in flight
+const (
+	emailBatchSize        = 50
+	emailBatchConcurrency = 4
+)
+
+// FindContactsByEmail looks up contacts by email, keyed by the emails as
+// given. Emails without a contact are in the map with a nil contact.
 func (c *Client) FindContactsByEmail(emails []string) (map[string]*Contact, error) {
-	tmp := make([]string, len(emails))
-	for i := range emails {
-		tmp[i] = fmt.Sprintf("\"%v\"", emails[i])
+	return c.FindContactsByEmailContext(context.Background(), emails)
+}
+
+// FindContactsByEmailContext ...
+func (c *Client) FindContactsByEmailContext(ctx context.Context, emails []string) (map[string]*Contact, error) {
+	// lookups are made once per email, ignoring case
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, e := range emails {
+		k := normalizeEmail(e)
+		if k != "" && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
 	}
-	q := strings.Join(tmp, ",")
 
-	vals := url.Values{}
-	vals.Add("email_ids", fmt.Sprintf("[%v]", q))
-	q = vals.Encode()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		found    = map[string]*Contact{}
+		sem      = make(chan struct{}, emailBatchConcurrency)
+	)
+	for start := 0; start < len(keys); start += emailBatchSize {
+		end := start + emailBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
 
-	// fmt.Printf("query: \n%v\n", out)
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			cl, err := c.findContactsByEmail(ctx, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+
+			// results are matched by their emails, since the API leaves out
+			// the ones it can't find
+			for _, ctc := range cl {
+				if ctc == nil {
+					continue
+				}
+				for _, p := range ctc.Emails() {
+					if k := normalizeEmail(p.Value); seen[k] && found[k] == nil {
+						found[k] = ctc
+					}
+				}
+			}
+		}(keys[start:end])
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
 
 	out := map[string]*Contact{}
+	for _, e := range emails {
+		out[e] = found[normalizeEmail(e)]
+	}
+	return out, nil
+}
 
-	req, err := c.postForm("POST", "api/contacts/search/email", strings.NewReader(q), nil)
+// findContactsByEmail makes one batch lookup
+func (c *Client) findContactsByEmail(ctx context.Context, emails []string) (ContactList, error) {
+	bits, err := json.Marshal(emails)
 	if err != nil {
-		return out, err
+		return nil, err
 	}
 
-	cl := ContactList{}
-	st, err := c.processResults(req, &cl)
-	if st == http.StatusOK && err == nil {
-		for i, v := range emails {
-			out[v] = cl[i]
-		}
+	vals := url.Values{}
+	vals.Add("email_ids", string(bits))
 
-		return out, nil
+	req, err := c.postForm(idempotent(ctx), "POST", "api/contacts/search/email", strings.NewReader(vals.Encode()), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
-	}
-	return nil, err
+	cl := ContactList{}
+	_, err = c.processResults(req, &cl)
+	return cl, err
 }
 
-// createContact ...
-func (c *Client) createContact(in Contact) (*Contact, error) {
-	st, err := c.send("POST", "api/contacts", nil, &in, &in)
-	if st == http.StatusOK && err == nil {
-		return &in, nil
-	}
+// normalizeEmail ...
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
-	case http.StatusNotAcceptable:
-		return nil, ErrContactLimit
+// createContact ...
+func (c *Client) createContact(ctx context.Context, in Contact) (*Contact, error) {
+	_, err := c.send(ctx, "POST", "api/contacts", nil, &in, &in)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, err
+	return &in, nil
 }
 
 // CreateContact ...
 func (c *Client) CreateContact(in Contact) (*Contact, error) {
+	return c.CreateContactContext(context.Background(), in)
+}
+
+// CreateContactContext ...
+func (c *Client) CreateContactContext(ctx context.Context, in Contact) (*Contact, error) {
 	in.Type = TypeContact
-	return c.createContact(in)
+	return c.createContact(ctx, in)
 }
 
 // _updateContact ...
-func (c *Client) _updateContact(in Contact, route string) (*Contact, error) {
-	st, err := c.send("PUT", route, nil, &in, &in)
-	if st == http.StatusOK && err == nil {
-		return &in, nil
+func (c *Client) _updateContact(ctx context.Context, in Contact, route string) (*Contact, error) {
+	_, err := c.send(ctx, "PUT", route, nil, &in, &in)
+	if err != nil {
+		return nil, err
 	}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
-	}
-	return nil, err
+	return &in, nil
 }
 
 // UpdateContactProperties can update the properties of a contact using this method. To update
 // lead score, star value, or tags, use the appropriate method
 func (c *Client) UpdateContactProperties(id int64, in Contact) (*Contact, error) {
-	in.ID = id
-	return c._updateContact(in, "api/contacts/edit-properties")
+	return c.UpdateContactPropertiesContext(context.Background(), id, in)
+}
+
+// UpdateContactPropertiesContext ...
+func (c *Client) UpdateContactPropertiesContext(ctx context.Context, id int64, in Contact) (*Contact, error) {
+	in.ID = ID(id)
+	return c._updateContact(ctx, in, "api/contacts/edit-properties")
 }
 
 // UpdateLeadScore ...
 func (c *Client) UpdateContactLeadScore(id, score int64) (*Contact, error) {
-	ctc := Contact{ID: id, LeadScore: int(score)}
-	return c._updateContact(ctc, "api/contacts/edit/lead-score")
+	return c.UpdateContactLeadScoreContext(context.Background(), id, score)
 }
 
-// UpdateContactStarValue ...
+// UpdateContactLeadScoreContext ...
+func (c *Client) UpdateContactLeadScoreContext(ctx context.Context, id, score int64) (*Contact, error) {
+	ctc := Contact{ID: ID(id), LeadScore: Int(score)}
+	return c._updateContact(ctx, ctc, "api/contacts/edit/lead-score")
+}
+
+// UpdateContactStarValue sets the star value, from 0 to 5
 func (c *Client) UpdateContactStarValue(id, star int64) (*Contact, error) {
-	ctc := Contact{ID: id, StarValue: int(star)}
-	return c._updateContact(ctc, "api/contacts/edit/add-star")
+	return c.UpdateContactStarValueContext(context.Background(), id, star)
+}
+
+// UpdateContactStarValueContext ...
+func (c *Client) UpdateContactStarValueContext(ctx context.Context, id, star int64) (*Contact, error) {
+	if star < minStarValue || star > maxStarValue {
+		return nil, ErrStarValue
+	}
+
+	ctc := Contact{ID: ID(id), StarValue: Int(star)}
+	return c._updateContact(ctx, ctc, "api/contacts/edit/add-star")
 }
 
 // UpdateContactTags ...
 func (c *Client) UpdateContactTags(id int64, tags []string) (*Contact, error) {
-	ctc := Contact{ID: id, Tags: tags}
-	return c._updateContact(ctc, "api/contacts/edit/tags")
+	return c.UpdateContactTagsContext(context.Background(), id, tags)
+}
+
+// UpdateContactTagsContext ...
+func (c *Client) UpdateContactTagsContext(ctx context.Context, id int64, tags []string) (*Contact, error) {
+	ctc := Contact{ID: ID(id), Tags: tags}
+	return c._updateContact(ctx, ctc, "api/contacts/edit/tags")
 }
 
 // DeleteContactTags ...
 func (c *Client) DeleteContactTags(id int64, tags []string) (*Contact, error) {
-	ctc := Contact{ID: id, Tags: tags}
-	return c._updateContact(ctc, "api/contacts/delete/tags")
+	return c.DeleteContactTagsContext(context.Background(), id, tags)
+}
+
+// DeleteContactTagsContext ...
+func (c *Client) DeleteContactTagsContext(ctx context.Context, id int64, tags []string) (*Contact, error) {
+	ctc := Contact{ID: ID(id), Tags: tags}
+	return c._updateContact(ctx, ctc, "api/contacts/delete/tags")
 }
 
 // DeleteContact ...
 func (c *Client) DeleteContact(id int) error {
+	return c.DeleteContactContext(context.Background(), id)
+}
+
+// DeleteContactContext ...
+func (c *Client) DeleteContactContext(ctx context.Context, id int) error {
 	r := fmt.Sprintf("api/contacts/%v", id)
-	return c.delete(r)
+	return c.delete(ctx, r)
 }
 
 // SearchContacts ...
 func (c *Client) SearchContacts(query string) (ContactList, error) {
-	return ContactList{}, nil
+	return c.SearchContactsContext(context.Background(), query)
+}
+
+// SearchContactsContext ...
+func (c *Client) SearchContactsContext(ctx context.Context, query string) (ContactList, error) {
+	return c.SearchContactsPageContext(ctx, query, SearchOptions{})
+}
+
+// SearchContactsPage runs a keyword search over contacts or companies,
+// depending on opts.Type
+func (c *Client) SearchContactsPage(query string, opts SearchOptions) (ContactList, error) {
+	return c.SearchContactsPageContext(context.Background(), query, opts)
+}
+
+// SearchContactsPageContext ...
+func (c *Client) SearchContactsPageContext(ctx context.Context, query string, opts SearchOptions) (ContactList, error) {
+	if opts.Type == "" {
+		opts.Type = TypeContact
+	}
+	if opts.Type != TypeContact && opts.Type != TypeCompany {
+		return ContactList{}, fmt.Errorf("can't search contacts with type %v", opts.Type)
+	}
+
+	out := ContactList{}
+	err := c.search(ctx, query, opts, &out)
+	return out, err
 }
@@ -0,0 +1,113 @@
+package agilecrm_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestFindContactsByEmail(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	// enough contacts for several batches looked up concurrently
+	const stored = 120
+	ids := map[string]agilecrm.ID{}
+	for i := 0; i < stored; i++ {
+		email := fmt.Sprintf("user%v@example.com", i)
+		ids[email] = newContact(t, cl, email).ID
+	}
+
+	// every stored contact plus a missing one, spread over three batches
+	all := []string{"nobody@example.com"}
+	want := map[string]string{"nobody@example.com": ""}
+	for i := 0; i < stored; i++ {
+		email := fmt.Sprintf("user%v@example.com", i)
+		all = append(all, email)
+		want[email] = email
+	}
+
+	tests := []struct {
+		name     string
+		emails   []string
+		want     map[string]string
+		requests int
+	}{
+		{
+			name:     "none",
+			emails:   nil,
+			want:     map[string]string{},
+			requests: 0,
+		},
+		{
+			name:   "case and spacing are ignored",
+			emails: []string{"USER1@example.com", " user2@example.com ", "user1@example.com"},
+			want: map[string]string{
+				"USER1@example.com":   "user1@example.com",
+				" user2@example.com ": "user2@example.com",
+				"user1@example.com":   "user1@example.com",
+			},
+			requests: 1,
+		},
+		{
+			name:     "missing emails map to nil",
+			emails:   []string{"user3@example.com", "nobody@example.com"},
+			want:     map[string]string{"user3@example.com": "user3@example.com", "nobody@example.com": ""},
+			requests: 1,
+		},
+		{
+			name:     "batched",
+			emails:   all,
+			want:     want,
+			requests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := srv.Requests()
+			got, err := cl.FindContactsByEmail(tt.emails)
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+
+			if n := srv.Requests() - before; n != tt.requests {
+				t.Errorf("requests = %v, want %v", n, tt.requests)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v emails, want %v", len(got), len(tt.want))
+			}
+
+			for key, email := range tt.want {
+				ctc, ok := got[key]
+				switch {
+				case !ok:
+					t.Errorf("%q missing from the results", key)
+				case email == "" && ctc != nil:
+					t.Errorf("%q matched contact %v, want none", key, ctc.ID)
+				case email != "" && (ctc == nil || ctc.ID != ids[email]):
+					t.Errorf("%q matched %v, want contact %v", key, ctc, ids[email])
+				}
+			}
+		})
+	}
+}
+
+func TestFindContactsByEmailBatchError(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	emails := []string{}
+	for i := 0; i < 120; i++ {
+		emails = append(emails, fmt.Sprintf("user%v@example.com", i))
+	}
+
+	// one failing batch fails the whole lookup
+	srv.Inject(agilecrmtest.Fault{Method: "POST", Route: "api/contacts/search/email", Status: http.StatusInternalServerError, Times: 1})
+	if _, err := cl.FindContactsByEmail(emails); err == nil {
+		t.Fatal("expected the failed batch to be reported")
+	}
+}
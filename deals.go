@@ -1,22 +1,26 @@
 package agilecrm
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type Deal struct {
-	ID            int64       `json:"id,omitempty"`
+	ID            ID          `json:"id,omitempty"`
 	Name          string      `json:"name,omitempty"`
 	Description   string      `json:"description,omitempty"`
-	ExpectedValue float64     `json:"expected_value,omitempty"`
-	PipelineID    int64       `json:"pipeline_id,omitempty"`
+	ExpectedValue Float       `json:"expected_value,omitempty"`
+	PipelineID    ID          `json:"pipeline_id,omitempty"`
 	Milestone     string      `json:"milestone,omitempty"`
-	Probabilty    int         `json:"probabilty,omitempty"`
-	CloseDate     int         `json:"close_date,omitempty"`
-	CreatedTime   int         `json:"created_time,omitempty"`
-	OwnerID       string      `json:"owner_id,omitempty"`
+	Probabilty    Int         `json:"probabilty,omitempty"`
+	CloseDate     Time        `json:"close_date,omitempty"`
+	CreatedTime   Time        `json:"created_time,omitempty"`
+	OwnerID       ID          `json:"owner_id,omitempty"`
 	Prefs         string      `json:"prefs,omitempty"`
+	Tags          []string    `json:"tags,omitempty"`
 	Contacts      ContactList `json:"contacts,omitempty"`
 	ContactIds    []string    `json:"contact_ids,omitempty"`
 	Cursor        string      `json:"cursor,omitempty"`
@@ -35,6 +39,11 @@ func (dl DealList) Cursor() string {
 
 // ListDeals ...
 func (c *Client) ListDeals(perPage int, cursor string) (DealList, error) {
+	return c.ListDealsContext(context.Background(), perPage, cursor)
+}
+
+// ListDealsContext ...
+func (c *Client) ListDealsContext(ctx context.Context, perPage int, cursor string) (DealList, error) {
 	out := DealList{}
 
 	params := map[string]string{}
@@ -45,20 +54,14 @@ func (c *Client) ListDeals(perPage int, cursor string) (DealList, error) {
 		params["cursor"] = cursor
 	}
 
-	st, err := c.get("GET", "api/opportunity", nil, params, &out)
-
-	if st == http.StatusOK && err == nil {
-		// everything's fine, just return
-		return out, nil
+	st, err := c.get(ctx, "GET", "api/opportunity", nil, params, &out)
+	if err != nil {
+		return out, err
 	}
 
-	// if the status code is one of the defined status from the API,
-	// return the proper error message
-	switch st {
-	case http.StatusNoContent:
-		return out, ErrNoContacts
-	case http.StatusUnauthorized:
-		return out, ErrUnauthorized
+	// no deals at all is reported with a 204
+	if st == http.StatusNoContent {
+		return out, statusError("GET", "api/opportunity", st, ErrNoContacts)
 	}
 
 	return out, nil
@@ -66,9 +69,14 @@ func (c *Client) ListDeals(perPage int, cursor string) (DealList, error) {
 
 // FindDealByID ...
 func (c *Client) FindDealByID(id int) (*Deal, error) {
+	return c.FindDealByIDContext(context.Background(), id)
+}
+
+// FindDealByIDContext ...
+func (c *Client) FindDealByIDContext(ctx context.Context, id int) (*Deal, error) {
 	r := fmt.Sprintf("api/opportunity/%v", id)
 	out := Deal{}
-	err := c.findByID(r, &out)
+	err := c.findByID(ctx, r, &out)
 	if err != nil {
 		return nil, err
 	}
@@ -77,57 +85,254 @@ func (c *Client) FindDealByID(id int) (*Deal, error) {
 
 // CreateDeal ...
 func (c *Client) CreateDeal(in Deal) (*Deal, error) {
-	st, err := c.send("POST", "api/opportunity", nil, in, &in)
+	return c.CreateDealContext(context.Background(), in)
+}
 
-	if st == http.StatusOK && err == nil {
-		return &in, nil
+// CreateDealContext ...
+func (c *Client) CreateDealContext(ctx context.Context, in Deal) (*Deal, error) {
+	if c.validateMilestones {
+		if err := c.ValidateDealMilestoneContext(ctx, in); err != nil {
+			return nil, err
+		}
 	}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
+	_, err := c.send(ctx, "POST", "api/opportunity", nil, in, &in)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, err
+	return &in, nil
 }
 
 // UpdateDeal ...
 func (c *Client) UpdateDeal(id int64, in Deal) (*Deal, error) {
-	in.ID = id
-	st, err := c.send("PUT", "api/opportunity/partial-update", nil, in, &in)
-	if st == http.StatusOK && err == nil {
-		return &in, nil
+	return c.UpdateDealContext(context.Background(), id, in)
+}
+
+// UpdateDealContext ...
+func (c *Client) UpdateDealContext(ctx context.Context, id int64, in Deal) (*Deal, error) {
+	if c.validateMilestones {
+		if err := c.validateDealUpdate(ctx, id, in); err != nil {
+			return nil, err
+		}
 	}
 
-	switch st {
-	case http.StatusUnauthorized:
-		return nil, ErrUnauthorized
-	case http.StatusBadRequest:
-		return nil, ErrWrongFormat
+	in.ID = ID(id)
+	_, err := c.send(ctx, "PUT", "api/opportunity/partial-update", nil, in, &in)
+	if err != nil {
+		return nil, err
 	}
-	return nil, err
+
+	return &in, nil
+}
+
+// patchDeal is UpdateDeal without the milestone validation, for changes
+// checked beforehand
+func (c *Client) patchDeal(ctx context.Context, id int64, in Deal) error {
+	in.ID = ID(id)
+	_, err := c.send(ctx, "PUT", "api/opportunity/partial-update", nil, in, &in)
+	return err
+}
+
+// SearchDeals runs a keyword search over deals
+func (c *Client) SearchDeals(query string, perPage int, cursor string) (DealList, error) {
+	return c.SearchDealsContext(context.Background(), query, perPage, cursor)
+}
+
+// SearchDealsContext ...
+func (c *Client) SearchDealsContext(ctx context.Context, query string, perPage int, cursor string) (DealList, error) {
+	opts := SearchOptions{Type: TypeDeal, PerPage: perPage, Cursor: cursor}
+	out := DealList{}
+	err := c.search(ctx, query, opts, &out)
+	return out, err
 }
 
 // DeleteDeal ...
 func (c *Client) DeleteDeal(id int) error {
+	return c.DeleteDealContext(context.Background(), id)
+}
+
+// DeleteDealContext ...
+func (c *Client) DeleteDealContext(ctx context.Context, id int) error {
 	r := fmt.Sprintf("api/opportunity/%v", id)
-	return c.delete(r)
+	return c.delete(ctx, r)
+}
+
+// CreateDealForEmail creates a deal linked to the contact with the email.
+// When no contact has it, the error wraps ErrNoSuchEmail, unless
+// createContact is set, in which case a contact with just the email is
+// created first. When several contacts have it, the error wraps
+// ErrDuplicateEmail and no deal is created.
+func (c *Client) CreateDealForEmail(email string, in Deal, createContact bool) (*Deal, error) {
+	return c.CreateDealForEmailContext(context.Background(), email, in, createContact)
+}
+
+// CreateDealForEmailContext ...
+func (c *Client) CreateDealForEmailContext(ctx context.Context, email string, in Deal, createContact bool) (*Deal, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, fmt.Errorf("email is required to create a deal for it")
+	}
+
+	// two results are enough to tell the email apart from a duplicate
+	q := Filter().Where(PropEmail, FilterEqual, email).PageSize(2)
+	cl, err := c.FilterContactsContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(cl) > 1:
+		return nil, fmt.Errorf("email %q: %w", email, ErrDuplicateEmail)
+
+	case len(cl) == 0 && !createContact:
+		return nil, fmt.Errorf("email %q: %w", email, ErrNoSuchEmail)
+
+	case len(cl) == 0:
+		ctc := Contact{}
+		ctc.SetEmail("", email)
+		if _, err := c.CreateContactContext(ctx, ctc); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.validateMilestones {
+		if err := c.ValidateDealMilestoneContext(ctx, in); err != nil {
+			return nil, err
+		}
+	}
+
+	r := fmt.Sprintf("api/opportunity/email/%v", url.PathEscape(email))
+	if _, err := c.send(ctx, "POST", r, nil, in, &in); err != nil {
+		return nil, err
+	}
+	return &in, nil
 }
 
-// TODO: create deal for a contact using email
+// DealStatus selects deals by where they are in their pipeline
+type DealStatus string
 
-// TODO: bulk delete
+const (
+	DealsAll  DealStatus = ""
+	DealsOpen DealStatus = "open"
+	DealsWon  DealStatus = "won"
+	DealsLost DealStatus = "lost"
+)
 
-// TODO: get deals from default track grouped by milestone
+// DealOptions selects a page of a deal listing. A deal is open until it
+// reaches its pipeline's won or lost milestone. With a status, up to 10
+// pages of the listing are read in one call to find deals that have it.
+type DealOptions struct {
+	Status  DealStatus
+	PerPage int
+	Cursor  string
+}
 
-// TODO: get deals for specific track grouped by milestone
+// GetContactDeals returns a page of the deals linked to a contact and the
+// cursor of the next page, which is empty after the last one. With a status,
+// the page can be empty even though more deals follow; see DealOptions.
+func (c *Client) GetContactDeals(contactID int64, opts DealOptions) (DealList, string, error) {
+	return c.GetContactDealsContext(context.Background(), contactID, opts)
+}
 
-// TODO: get deals for specific track
+// GetContactDealsContext ...
+func (c *Client) GetContactDealsContext(ctx context.Context, contactID int64, opts DealOptions) (DealList, string, error) {
+	return c.listDealsBy(ctx, contactDealsRoute(contactID), opts, nil)
+}
 
-// TODO: get deals for specific contact
+// contactDealsRoute ...
+func contactDealsRoute(contactID int64) string {
+	return fmt.Sprintf("api/contacts/%v/deals", contactID)
+}
 
-// TODO: get deals for current user
+// ListMyDeals returns a page of the deals owned by the user the client is
+// authenticated as and the cursor of the next page, the same way
+// GetContactDeals does
+func (c *Client) ListMyDeals(opts DealOptions) (DealList, string, error) {
+	return c.ListMyDealsContext(context.Background(), opts)
+}
+
+// ListMyDealsContext ...
+func (c *Client) ListMyDealsContext(ctx context.Context, opts DealOptions) (DealList, string, error) {
+	return c.listDealsBy(ctx, myDealsRoute, opts, nil)
+}
+
+const myDealsRoute = "api/opportunity/my/deals"
+
+// maxStatusPages caps the pages listDealsBy reads in one call looking for
+// deals with the requested status
+const maxStatusPages = 10
+
+// listDealsBy fetches a page of a deal listing, keeping the deals with the
+// requested status, and returns it with the cursor of the next page. Pages
+// left empty by the status are skipped, up to maxStatusPages of them, so a
+// page can hold fewer deals than asked for, or none, without being the last
+// one.
+//
+// The status is told from the account's pipelines, which are loaded into
+// pipelines the first time they are needed, so callers fetching several
+// pages can share them. A nil pipelines loads them for this call only.
+func (c *Client) listDealsBy(ctx context.Context, route string, opts DealOptions, pipelines *PipelineList) (DealList, string, error) {
+	if pipelines == nil {
+		pipelines = new(PipelineList)
+	}
+
+	switch opts.Status {
+	case DealsAll:
+	case DealsOpen, DealsWon, DealsLost:
+		if *pipelines == nil {
+			pl, err := c.ListPipelinesContext(ctx)
+			if err != nil {
+				return nil, "", err
+			}
+			*pipelines = pl
+		}
+	default:
+		return nil, "", fmt.Errorf("unknown deal status %q", opts.Status)
+	}
+
+	cursor := opts.Cursor
+	for n := 1; ; n++ {
+		params := map[string]string{}
+		if opts.PerPage > 0 {
+			params["page_size"] = fmt.Sprintf("%v", opts.PerPage)
+		}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+
+		// no deals is reported with a 204, which leaves page empty
+		page := DealList{}
+		if _, err := c.get(ctx, "GET", route, nil, params, &page); err != nil {
+			return nil, "", err
+		}
+
+		next := page.Cursor()
+		if next == cursor {
+			next = ""
+		}
+		if opts.Status == DealsAll {
+			return page, next, nil
+		}
+
+		out := DealList{}
+		for _, d := range page {
+			if pipelines.DealStatus(d) == opts.Status {
+				out = append(out, d)
+			}
+		}
+
+		// the cursor is carried by the last deal of the page, which may
+		// have been left out
+		if len(out) > 0 {
+			out[len(out)-1].Cursor = next
+			return out, next, nil
+		}
+		if next == "" || n >= maxStatusPages {
+			return out, next, nil
+		}
+		cursor = next
+	}
+}
 
 // TODO: remove deal contacts
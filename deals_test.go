@@ -0,0 +1,185 @@
+package agilecrm_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+// addDeals stores a deal at each milestone of the default pipeline, named
+// after it, in order
+func addDeals(srv *agilecrmtest.Server, contactID agilecrm.ID, milestones ...string) {
+	for _, m := range milestones {
+		d := agilecrm.Deal{Name: m, PipelineID: agilecrmtest.DefaultPipelineID, Milestone: m}
+		if contactID != 0 {
+			d.ContactIds = []string{contactID.String()}
+		}
+		srv.AddDeal(d)
+	}
+}
+
+// dealNames ...
+func dealNames(dl agilecrm.DealList) []string {
+	out := []string{}
+	for _, d := range dl {
+		out = append(out, d.Name)
+	}
+	return out
+}
+
+func TestListMyDealsStatus(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	addDeals(srv, 0, "New", "Won", "Prospect", "Lost")
+	srv.AddDeal(agilecrm.Deal{Name: "theirs", PipelineID: agilecrmtest.DefaultPipelineID, Milestone: "New", OwnerID: 99})
+
+	tests := []struct {
+		status agilecrm.DealStatus
+		want   []string
+	}{
+		{agilecrm.DealsAll, []string{"New", "Won", "Prospect", "Lost"}},
+		{agilecrm.DealsOpen, []string{"New", "Prospect"}},
+		{agilecrm.DealsWon, []string{"Won"}},
+		{agilecrm.DealsLost, []string{"Lost"}},
+	}
+
+	for _, tt := range tests {
+		dl, cursor, err := cl.ListMyDeals(agilecrm.DealOptions{Status: tt.status})
+		if err != nil {
+			t.Fatalf("%q: unexpected error; %v", tt.status, err)
+		}
+		if got := dealNames(dl); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: deals = %v, want %v", tt.status, got, tt.want)
+		}
+		if cursor != "" {
+			t.Errorf("%q: cursor %q on the only page", tt.status, cursor)
+		}
+	}
+
+	if _, _, err := cl.ListMyDeals(agilecrm.DealOptions{Status: "pending"}); err == nil {
+		t.Error("expected an unknown status to fail")
+	}
+}
+
+func TestListMyDealsCursor(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	// the first page has no won deals, and the won deal of the second page
+	// isn't its last one
+	addDeals(srv, 0, "New", "Prospect", "Won", "Proposal", "New", "Won")
+
+	opts := agilecrm.DealOptions{Status: agilecrm.DealsWon, PerPage: 2}
+	got := []agilecrm.ID{}
+	for pages := 0; pages < 5; pages++ {
+		dl, cursor, err := cl.ListMyDeals(opts)
+		if err != nil {
+			t.Fatalf("unexpected error; %v", err)
+		}
+		if len(dl) != 1 {
+			t.Fatalf("page %v has %v won deals, want 1", pages, len(dl))
+		}
+		got = append(got, dl[0].ID)
+
+		// the deal carries the cursor of the page it was on
+		if dl.Cursor() != cursor {
+			t.Errorf("page %v: deal cursor %q, returned %q", pages, dl.Cursor(), cursor)
+		}
+		if cursor == "" {
+			break
+		}
+		opts.Cursor = cursor
+	}
+
+	want := []agilecrm.ID{}
+	for _, d := range srv.Deals() {
+		if d.Milestone == "Won" {
+			want = append(want, d.ID)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("won deals = %v, want %v", got, want)
+	}
+}
+
+func TestListMyDealsPageCap(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	for i := 0; i < 25; i++ {
+		addDeals(srv, 0, "New")
+	}
+	addDeals(srv, 0, "Won")
+
+	// one call stops after 10 pages without a won deal, returning where it
+	// stopped
+	before := srv.Requests()
+	dl, cursor, err := cl.ListMyDeals(agilecrm.DealOptions{Status: agilecrm.DealsWon, PerPage: 1})
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if len(dl) != 0 || cursor == "" {
+		t.Fatalf("got %v deals with cursor %q, want none and a cursor", len(dl), cursor)
+	}
+	if n := srv.Requests() - before; n != 11 {
+		t.Errorf("made %v requests, want the pipelines and 10 pages", n)
+	}
+
+	// the iterator reads on until it finds it
+	it := cl.IterMyDeals(agilecrm.DealsWon, 1)
+	names := []string{}
+	for it.Next(context.Background()) {
+		names = append(names, it.Deal().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"Won"}) {
+		t.Errorf("iterated %v, want the won deal", names)
+	}
+}
+
+func TestIterContactDeals(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	c := newContact(t, cl, "deals@example.com")
+	addDeals(srv, c.ID, "New", "Lost", "Won", "Proposal", "Lost", "New")
+	addDeals(srv, 0, "Lost")
+
+	it := cl.IterContactDeals(int64(c.ID), agilecrm.DealsLost, 1)
+	names := []string{}
+	for it.Next(context.Background()) {
+		names = append(names, it.Deal().Name)
+		if len(it.Deal().ContactIds) != 1 {
+			t.Errorf("deal %v not linked to the contact", it.Deal().ID)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"Lost", "Lost"}) {
+		t.Errorf("iterated %v, want the two lost deals", names)
+	}
+
+	// pipelines are loaded once for the whole walk
+	before := srv.Requests()
+	it = cl.IterContactDeals(int64(c.ID), agilecrm.DealsOpen, 2)
+	for it.Next(context.Background()) {
+	}
+	if n := srv.Requests() - before; n != 4 {
+		t.Errorf("made %v requests, want the pipelines and 3 pages", n)
+	}
+
+	dl, _, err := cl.GetContactDeals(int64(c.ID), agilecrm.DealOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if len(dl) != 6 {
+		t.Errorf("%v deals, want 6", len(dl))
+	}
+}
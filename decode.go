@@ -0,0 +1,298 @@
+package agilecrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The API sends IDs and numbers as json strings from some endpoints and as
+// numbers from others. ID, Int and Float decode from either, as well as from
+// null and empty strings, and always encode as numbers.
+
+// ID is a record ID
+type ID int64
+
+// Int is an integer field
+type Int int
+
+// Float is a decimal field, like a deal's value
+type Float float64
+
+// numberBytes unwraps a string-encoded number, returning nil for null and
+// empty strings
+func numberBytes(b []byte) ([]byte, error) {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+
+	if len(b) > 0 && b[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, err
+		}
+		b = bytes.TrimSpace([]byte(s))
+	}
+
+	if len(b) == 0 {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// typeError reports a value that can't be decoded into the type. Its field
+// is filled in by decodeError, since the json package leaves it empty for
+// errors returned by UnmarshalJSON methods.
+func typeError(b []byte, t reflect.Type) error {
+	return &json.UnmarshalTypeError{Value: fmt.Sprintf("%q", b), Type: t}
+}
+
+// String ...
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// UnmarshalJSON ...
+func (id *ID) UnmarshalJSON(b []byte) error {
+	b, err := numberBytes(b)
+	if err != nil || b == nil {
+		*id = 0
+		return err
+	}
+
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return typeError(b, reflect.TypeOf(*id))
+	}
+	*id = ID(n)
+	return nil
+}
+
+// UnmarshalJSON ...
+func (i *Int) UnmarshalJSON(b []byte) error {
+	b, err := numberBytes(b)
+	if err != nil || b == nil {
+		*i = 0
+		return err
+	}
+
+	// integers are sometimes sent with a fraction, like 50.0
+	n, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return typeError(b, reflect.TypeOf(*i))
+	}
+	*i = Int(n)
+	return nil
+}
+
+// UnmarshalJSON ...
+func (f *Float) UnmarshalJSON(b []byte) error {
+	b, err := numberBytes(b)
+	if err != nil || b == nil {
+		*f = 0
+		return err
+	}
+
+	n, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return typeError(b, reflect.TypeOf(*f))
+	}
+	*f = Float(n)
+	return nil
+}
+
+// DecodeError is a response that could not be decoded, in whole or in part
+type DecodeError struct {
+	Method string
+	Route  string
+
+	// Index is the position of the list element that failed, or -1 when
+	// the response isn't a list
+	Index int
+
+	// Field is the path of the field that failed, when known
+	Field string
+
+	Err error
+}
+
+// Error ...
+func (e *DecodeError) Error() string {
+	where := fmt.Sprintf("%v %v", e.Method, e.Route)
+	if e.Index >= 0 {
+		where += fmt.Sprintf(" element %v", e.Index)
+	}
+	if e.Field != "" {
+		where += fmt.Sprintf(" field %v", e.Field)
+	}
+	return fmt.Sprintf("decoding %v: %v", where, e.Err)
+}
+
+// Unwrap ...
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError describes a failure to decode raw into a value of type t
+func (c *Client) decodeError(req *http.Request, idx int, err error, raw []byte, t reflect.Type) *DecodeError {
+	e := &DecodeError{Method: req.Method, Route: c.routeOf(req), Index: idx, Err: err}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		e.Field = typeErr.Field
+		if e.Field == "" {
+			// fields are decoded in order, so the first failing one is the
+			// one the json package reports
+			if fe := fieldErrors(raw, t); len(fe) > 0 {
+				e.Field = fe[0].path
+			}
+		}
+	}
+	return e
+}
+
+// reportDecodeErrors reports every field of raw that failed to decode into
+// a value of type t, since the json package only returns the first one
+func (c *Client) reportDecodeErrors(req *http.Request, idx int, err error, raw []byte, t reflect.Type) {
+	fe := fieldErrors(raw, t)
+	if len(fe) == 0 {
+		c.reportDecodeError(c.decodeError(req, idx, err, raw, t))
+		return
+	}
+
+	for _, f := range fe {
+		c.reportDecodeError(&DecodeError{Method: req.Method, Route: c.routeOf(req), Index: idx, Field: f.path, Err: f.err})
+	}
+}
+
+// fieldError is a field that can't be decoded into its Go type
+type fieldError struct {
+	path string
+	err  error
+}
+
+// fieldErrors returns every field of raw that can't be decoded into its
+// field of t, in the order they appear
+func fieldErrors(raw []byte, t reflect.Type) []fieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	out := []fieldError{}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems := []json.RawMessage{}
+		if json.Unmarshal(raw, &elems) != nil {
+			return out
+		}
+		for _, el := range elems {
+			out = append(out, fieldErrors(el, t.Elem())...)
+		}
+
+	case reflect.Struct:
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return out
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return out
+			}
+			k, _ := tok.(string)
+			v := json.RawMessage{}
+			if err := dec.Decode(&v); err != nil {
+				return out
+			}
+
+			f, ok := jsonField(t, k)
+			if !ok {
+				continue
+			}
+			err = json.Unmarshal(v, reflect.New(f.Type).Interface())
+			if err == nil {
+				continue
+			}
+
+			sub := fieldErrors(v, f.Type)
+			if len(sub) == 0 {
+				out = append(out, fieldError{path: k, err: err})
+			}
+			for _, e := range sub {
+				out = append(out, fieldError{path: k + "." + e.path, err: e.err})
+			}
+		}
+	}
+	return out
+}
+
+// jsonField finds the field of the struct type that the json key decodes
+// into, ignoring case the way the json package does
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag = strings.Split(tag, ",")[0]; tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// decode unmarshals a response. In lenient mode the elements of a list are
+// decoded one at a time, and fields that fail to decode are reported to
+// Config.OnDecodeError instead of failing the call.
+func (c *Client) decode(req *http.Request, body []byte, out interface{}) error {
+	err := json.Unmarshal(body, out)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	rv := reflect.ValueOf(out)
+	if !c.lenient || errors.As(err, &syntaxErr) {
+		return c.decodeError(req, -1, err, body, rv.Type())
+	}
+
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		// what could be decoded is kept
+		c.reportDecodeErrors(req, -1, err, body, rv.Type())
+		return nil
+	}
+
+	raws := []json.RawMessage{}
+	if err := json.Unmarshal(body, &raws); err != nil {
+		return c.decodeError(req, -1, err, body, rv.Type())
+	}
+
+	list := reflect.MakeSlice(rv.Elem().Type(), 0, len(raws))
+	for i, raw := range raws {
+		elem := reflect.New(rv.Elem().Type().Elem())
+		if err := json.Unmarshal(raw, elem.Interface()); err != nil {
+			c.reportDecodeErrors(req, i, err, raw, elem.Type())
+		}
+		list = reflect.Append(list, elem.Elem())
+	}
+	rv.Elem().Set(list)
+	return nil
+}
+
+// reportDecodeError ...
+func (c *Client) reportDecodeError(e *DecodeError) {
+	if c.onDecodeError != nil {
+		c.onDecodeError(e)
+	}
+}
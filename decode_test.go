@@ -0,0 +1,264 @@
+package agilecrm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+func TestFlexibleNumbers(t *testing.T) {
+	tests := []struct {
+		in    string
+		id    agilecrm.ID
+		i     agilecrm.Int
+		f     agilecrm.Float
+		idErr bool
+		err   bool
+	}{
+		{in: `42`, id: 42, i: 42, f: 42},
+		{in: `"42"`, id: 42, i: 42, f: 42},
+		{in: `" 42 "`, id: 42, i: 42, f: 42},
+		{in: `null`},
+		{in: `""`},
+		{in: `"50.0"`, i: 50, f: 50, idErr: true},
+		{in: `50.5`, i: 50, f: 50.5, idErr: true},
+		{in: `"abc"`, err: true},
+		{in: `true`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			id, i, f := agilecrm.ID(7), agilecrm.Int(7), agilecrm.Float(7)
+			errs := []error{
+				json.Unmarshal([]byte(tt.in), &id),
+				json.Unmarshal([]byte(tt.in), &i),
+				json.Unmarshal([]byte(tt.in), &f),
+			}
+			want := []bool{tt.err || tt.idErr, tt.err, tt.err}
+			types := []reflect.Type{reflect.TypeOf(id), reflect.TypeOf(i), reflect.TypeOf(f)}
+
+			for n, err := range errs {
+				if !want[n] {
+					if err != nil {
+						t.Errorf("%v: unexpected error; %v", types[n], err)
+					}
+					continue
+				}
+				var typeErr *json.UnmarshalTypeError
+				if !errors.As(err, &typeErr) || typeErr.Type != types[n] {
+					t.Errorf("%v: error = %v, want a type error", types[n], err)
+				}
+			}
+
+			if !tt.err && !tt.idErr && id != tt.id {
+				t.Errorf("ID = %v, want %v", id, tt.id)
+			}
+			if !tt.err && (i != tt.i || f != tt.f) {
+				t.Errorf("Int, Float = %v, %v, want %v, %v", i, f, tt.i, tt.f)
+			}
+		})
+	}
+
+	// they always encode as numbers
+	bits, _ := json.Marshal(struct {
+		ID agilecrm.ID
+		I  agilecrm.Int
+		F  agilecrm.Float
+	}{42, 3, 1.5})
+	if string(bits) != `{"ID":42,"I":3,"F":1.5}` {
+		t.Errorf("encoded as %s", bits)
+	}
+}
+
+// fixedServer answers every request with the body
+func fixedServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+// decodeClient returns a client for the server, collecting the decode
+// errors it reports in lenient mode
+func decodeClient(t *testing.T, srv *httptest.Server, lenient bool) (*agilecrm.Client, func() []*agilecrm.DecodeError) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		errs []*agilecrm.DecodeError
+	)
+	cl, err := agilecrm.New(agilecrm.Config{
+		BaseURL:       srv.URL + "/dev/",
+		User:          "user",
+		Password:      "pass",
+		LenientDecode: lenient,
+		OnDecodeError: func(e *agilecrm.DecodeError) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, e)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cl, func() []*agilecrm.DecodeError {
+		mu.Lock()
+		defer mu.Unlock()
+		return errs
+	}
+}
+
+// decodeCase is a response body and the fields of it that can't be decoded
+type decodeCase struct {
+	name   string
+	body   string
+	list   bool
+	index  []int
+	fields []string
+}
+
+var decodeCases = []decodeCase{
+	{
+		name:   "one field",
+		body:   `{"id":"abc","name":"kept"}`,
+		index:  []int{-1},
+		fields: []string{"id"},
+	},
+	{
+		name:   "every field",
+		body:   `{"id":"abc","expected_value":"x","name":"kept","close_date":"soon"}`,
+		index:  []int{-1, -1, -1},
+		fields: []string{"id", "expected_value", "close_date"},
+	},
+	{
+		name:   "list elements",
+		body:   `[{"id":1,"name":"kept"},{"id":"x","name":"kept","probabilty":"y"},{"id":3,"name":"kept"}]`,
+		list:   true,
+		index:  []int{1, 1},
+		fields: []string{"id", "probabilty"},
+	},
+	{
+		name:   "own and stdlib type errors",
+		body:   `{"id":1,"name":"kept","probabilty":true,"tags":"vip"}`,
+		index:  []int{-1, -1},
+		fields: []string{"probabilty", "tags"},
+	},
+}
+
+func TestDecodeLenient(t *testing.T) {
+	for _, tt := range decodeCases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fixedServer(tt.body)
+			defer srv.Close()
+			cl, reported := decodeClient(t, srv, true)
+
+			var err error
+			names := []string{}
+			if tt.list {
+				var dl agilecrm.DealList
+				dl, err = cl.ListDeals(0, "")
+				for _, d := range dl {
+					names = append(names, d.Name)
+				}
+			} else {
+				var d *agilecrm.Deal
+				d, err = cl.FindDealByID(1)
+				if d != nil {
+					names = append(names, d.Name)
+				}
+			}
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+
+			// the fields that decode are kept
+			for _, n := range names {
+				if n != "kept" {
+					t.Errorf("name = %q, want the decoded value", n)
+				}
+			}
+
+			errs := reported()
+			if len(errs) != len(tt.fields) {
+				t.Fatalf("reported %v errors, want %v: %v", len(errs), len(tt.fields), errs)
+			}
+			for i, e := range errs {
+				if e.Field != tt.fields[i] || e.Index != tt.index[i] {
+					t.Errorf("error %v at element %v field %q, want element %v field %q", i, e.Index, e.Field, tt.index[i], tt.fields[i])
+				}
+				var typeErr *json.UnmarshalTypeError
+				if !errors.As(e, &typeErr) {
+					t.Errorf("error %v = %v, want a type error", i, e.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	for _, tt := range decodeCases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fixedServer(tt.body)
+			defer srv.Close()
+			cl, reported := decodeClient(t, srv, false)
+
+			var err error
+			if tt.list {
+				_, err = cl.ListDeals(0, "")
+			} else {
+				_, err = cl.FindDealByID(1)
+			}
+
+			// the call fails on the first field
+			var de *agilecrm.DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("error = %v, want a DecodeError", err)
+			}
+			if de.Field != tt.fields[0] || de.Index != -1 {
+				t.Errorf("failed at element %v field %q, want field %q", de.Index, de.Field, tt.fields[0])
+			}
+			if n := len(reported()); n != 0 {
+				t.Errorf("%v errors reported outside lenient mode", n)
+			}
+		})
+	}
+}
+
+func TestDecodeNestedField(t *testing.T) {
+	srv := fixedServer(`{"id":1,"lead_score":"x","viewed":{"viewed_time":"soon"},"tags_with_time":[{"tag":"a","created_at":"never"}]}`)
+	defer srv.Close()
+	cl, reported := decodeClient(t, srv, true)
+
+	if _, err := cl.FindContactById(1); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	want := []string{"lead_score", "viewed.viewed_time", "tags_with_time.created_at"}
+	got := []string{}
+	for _, e := range reported() {
+		got = append(got, e.Field)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeSyntaxError(t *testing.T) {
+	srv := fixedServer(`{"id":1,`)
+	defer srv.Close()
+	cl, _ := decodeClient(t, srv, true)
+
+	// a broken body fails the call even in lenient mode
+	_, err := cl.FindDealByID(1)
+	var de *agilecrm.DecodeError
+	if !errors.As(err, &de) {
+		t.Fatalf("error = %v, want a DecodeError", err)
+	}
+}
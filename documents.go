@@ -1,17 +1,20 @@
 package agilecrm
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type Document struct {
-	ID           int64  `json:"id,omitempty"`
+	ID           ID     `json:"id,omitempty"`
 	Name         string `json:"name,omitempty"`
 	DummyName    string `json:"dummy_name,omitempty"`
-	UploadedTime int    `json:"uploaded_time,omitempty"`
+	UploadedTime Time   `json:"uploaded_time,omitempty"`
 	Extension    string `json:"extension,omitempty"`
 	DocType      string `json:"doc_type,omitempty"`
 	Text         string `json:"text,omitempty"`
 	TemplateType string `json:"template_type,omitempty"`
-	Size         int    `json:"size,omitempty"`
+	Size         Int    `json:"size,omitempty"`
 	NetworkType  string `json:"network_type,omitempty"`
 	URL          string `json:"url,omitempty"`
 	EntityType   string `json:"entity_type,omitempty"`
@@ -20,7 +23,7 @@ type Document struct {
 	CaseIds    []string `json:"case_ids,omitempty"`
 	DealIds    []string `json:"deal_ids,omitempty"`
 
-	Update int `json:"update,omitempty"`
+	Update Int `json:"update,omitempty"`
 
 	Owner *ContactUser `json:"owner,omitempty"`
 
@@ -45,26 +48,41 @@ type UpsertDoc struct {
 
 // GetContactDocuments ...
 func (c *Client) GetContactDocuments(id int64) (DocumentList, error) {
+	return c.GetContactDocumentsContext(context.Background(), id)
+}
+
+// GetContactDocumentsContext ...
+func (c *Client) GetContactDocumentsContext(ctx context.Context, id int64) (DocumentList, error) {
 	r := fmt.Sprintf("api/documents/contact/%v/docs", id)
 
 	out := DocumentList{}
-	_, err := c.get("GET", r, nil, nil, &out)
+	_, err := c.get(ctx, "GET", r, nil, nil, &out)
 
 	return out, err
 }
 
 // CreateContactDocument ...
 func (c *Client) CreateDocument(doc UpsertDoc) (*Document, error) {
+	return c.CreateDocumentContext(context.Background(), doc)
+}
+
+// CreateDocumentContext ...
+func (c *Client) CreateDocumentContext(ctx context.Context, doc UpsertDoc) (*Document, error) {
 	doc.ID = nil
 	out := &Document{}
-	_, err := c.send("POST", "api/documents", nil, doc, out)
+	_, err := c.send(ctx, "POST", "api/documents", nil, doc, out)
 	return out, err
 }
 
 // UpdateDocument ...
 func (c *Client) UpdateDocument(id int64, doc UpsertDoc) (*Document, error) {
+	return c.UpdateDocumentContext(context.Background(), id, doc)
+}
+
+// UpdateDocumentContext ...
+func (c *Client) UpdateDocumentContext(ctx context.Context, id int64, doc UpsertDoc) (*Document, error) {
 	doc.ID = &id
 	out := &Document{}
-	_, err := c.send("PUT", "api/documents", nil, doc, out)
+	_, err := c.send(ctx, "PUT", "api/documents", nil, doc, out)
 	return out, err
 }
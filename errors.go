@@ -0,0 +1,122 @@
+package agilecrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIError is returned by every Client method when the API answers with an
+// error status, or with a status that means failure for that call (like a
+// 204 on a lookup). It matches the package's sentinel errors through
+// errors.Is, so both
+//
+//	errors.Is(err, agilecrm.ErrUnauthorized)
+//
+// and
+//
+//	var apiErr *agilecrm.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+//
+// work on the same error.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Route      string
+
+	// Body is the raw response body, if any
+	Body []byte
+
+	// Message is the "exception message" sent by the API, if any
+	Message string
+
+	err error
+}
+
+// Error ...
+func (e *APIError) Error() string {
+	reason := e.Message
+	if reason == "" && e.err != nil {
+		reason = e.err.Error()
+	}
+	if reason == "" {
+		reason = strings.ToLower(http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%v %v failed with status %v; %v", e.Method, e.Route, e.StatusCode, reason)
+}
+
+// Unwrap returns the sentinel error matching the status, if there is one
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// subError is a sentinel error that is a more specific case of another one,
+// which it matches under errors.Is
+type subError struct {
+	msg    string
+	parent error
+}
+
+// Error ...
+func (e *subError) Error() string {
+	return e.msg
+}
+
+// Is ...
+func (e *subError) Is(target error) bool {
+	return target == e.parent
+}
+
+// statusSentinel maps the statuses documented by the API to their sentinel
+func statusSentinel(st int) error {
+	switch st {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusBadRequest:
+		return ErrWrongFormat
+	case http.StatusNotAcceptable:
+		return ErrContactLimit
+	}
+	return nil
+}
+
+// routeOf returns the route a request was made to, relative to the base URL
+func (c *Client) routeOf(req *http.Request) string {
+	route := req.URL.Path
+	if base, err := url.Parse(c.url); err == nil {
+		route = strings.TrimPrefix(route, base.Path)
+	}
+	return route
+}
+
+// apiError builds the error for a failed response
+func (c *Client) apiError(req *http.Request, st int, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: st,
+		Method:     req.Method,
+		Route:      c.routeOf(req),
+		Body:       body,
+		err:        statusSentinel(st),
+	}
+
+	msg := struct {
+		Msg string `json:"exception message"`
+	}{}
+	if json.Unmarshal(body, &msg) == nil {
+		e.Message = msg.Msg
+	}
+
+	return e
+}
+
+// statusError is used when a successful status still means the call failed
+func statusError(method, route string, st int, err error) *APIError {
+	return &APIError{
+		StatusCode: st,
+		Method:     method,
+		Route:      route,
+		err:        err,
+	}
+}
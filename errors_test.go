@@ -0,0 +1,83 @@
+package agilecrm_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		want    error
+	}{
+		{http.StatusUnauthorized, "", agilecrm.ErrUnauthorized},
+		{http.StatusBadRequest, "bad input", agilecrm.ErrWrongFormat},
+		{http.StatusNotAcceptable, "", agilecrm.ErrContactLimit},
+		{http.StatusInternalServerError, "boom", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+			srv.Inject(agilecrmtest.Fault{Route: "api/contacts", Status: tt.status, Message: tt.message})
+
+			_, err := cl.ListContacts(0, "")
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+
+			var apiErr *agilecrm.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %T, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Method != "GET" || apiErr.Route != "api/contacts" {
+				t.Errorf("got %v %v %v, want GET api/contacts %v", apiErr.Method, apiErr.Route, apiErr.StatusCode, tt.status)
+			}
+			if apiErr.Message != tt.message {
+				t.Errorf("message = %q, want %q", apiErr.Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestAPIErrorNoContent(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	// lookups answer a missing contact with a 204
+	_, err := cl.FindContactById(424242)
+	if !errors.Is(err, agilecrm.ErrNoSuchContact) {
+		t.Fatalf("error = %v, want %v", err, agilecrm.ErrNoSuchContact)
+	}
+
+	var apiErr *agilecrm.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNoContent {
+		t.Fatalf("error = %v, want a 204 APIError", err)
+	}
+}
+
+func TestNoSuchEmail(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	_, findErr := cl.FindContactByEmail("nobody@example.com")
+	_, dealErr := cl.CreateDealForEmail("nobody@example.com", agilecrm.Deal{Name: "lost"}, false)
+
+	// every email lookup fails the same way, which is also a missing contact
+	for _, err := range []error{findErr, dealErr} {
+		if !errors.Is(err, agilecrm.ErrNoSuchEmail) || !errors.Is(err, agilecrm.ErrNoSuchContact) {
+			t.Errorf("error = %v, want one matching both sentinels", err)
+		}
+	}
+
+	_, err := cl.FindContactById(424242)
+	if errors.Is(err, agilecrm.ErrNoSuchEmail) {
+		t.Errorf("missing ID %v matches ErrNoSuchEmail", err)
+	}
+}
@@ -1,18 +1,19 @@
 package agilecrm
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
 
 type Event struct {
-	ID             int64  `json:"id,omitempty"`
-	CreatedTime    int    `json:"created_time,omitempty"`
+	ID             ID     `json:"id,omitempty"`
+	CreatedTime    Time   `json:"created_time,omitempty"`
 	AllDay         bool   `json:"all_day,omitempty"`
 	Title          string `json:"title,omitempty"`
 	Color          string `json:"color,omitempty"`
-	Start          int    `json:"start,omitempty"`
-	End            int    `json:"end,omitempty"`
+	Start          Time   `json:"start,omitempty"`
+	End            Time   `json:"end,omitempty"`
 	IsEventStarred bool   `json:"is_event_starred,omitempty"`
 
 	Contacts ContactList `json:"contacts,omitempty"`
@@ -20,12 +21,12 @@ type Event struct {
 
 type EventUpsert struct {
 	ID             *int64   `json:"id,omitempty"`
-	CreatedTime    int      `json:"created_time,omitempty"`
+	CreatedTime    Time     `json:"created_time,omitempty"`
 	AllDay         bool     `json:"all_day,omitempty"`
 	Title          string   `json:"title,omitempty"`
 	Color          string   `json:"color,omitempty"`
-	Start          int      `json:"start,omitempty"`
-	End            int      `json:"end,omitempty"`
+	Start          Time     `json:"start,omitempty"`
+	End            Time     `json:"end,omitempty"`
 	IsEventStarred bool     `json:"is_event_starred,omitempty"`
 	Contacts       []string `json:"contacts,omitempty"`
 }
@@ -34,6 +35,11 @@ type EventList []Event
 
 // ListEvents ...
 func (c *Client) ListEvents(start, end time.Time) (EventList, error) {
+	return c.ListEventsContext(context.Background(), start, end)
+}
+
+// ListEventsContext ...
+func (c *Client) ListEventsContext(ctx context.Context, start, end time.Time) (EventList, error) {
 	s := start.Unix()
 	e := end.Unix()
 
@@ -43,36 +49,56 @@ func (c *Client) ListEvents(start, end time.Time) (EventList, error) {
 	}
 
 	out := EventList{}
-	_, err := c.get("GET", "api/events", nil, params, &out)
+	_, err := c.get(ctx, "GET", "api/events", nil, params, &out)
 
 	return out, err
 }
 
 // GetContactEvents ...
 func (c *Client) GetContactEvents(id int64) (EventList, error) {
+	return c.GetContactEventsContext(context.Background(), id)
+}
+
+// GetContactEventsContext ...
+func (c *Client) GetContactEventsContext(ctx context.Context, id int64) (EventList, error) {
 	r := fmt.Sprintf("api/contacts/%v/events/sort", id)
 	out := EventList{}
-	_, err := c.get("GET", r, nil, nil, &out)
+	_, err := c.get(ctx, "GET", r, nil, nil, &out)
 	return out, err
 }
 
 // CreateEvent ...
 func (c *Client) CreateEvent(e EventUpsert) (*Event, error) {
+	return c.CreateEventContext(context.Background(), e)
+}
+
+// CreateEventContext ...
+func (c *Client) CreateEventContext(ctx context.Context, e EventUpsert) (*Event, error) {
 	out := &Event{}
-	_, err := c.send("POST", "api/events", nil, e, out)
+	_, err := c.send(ctx, "POST", "api/events", nil, e, out)
 	return out, err
 }
 
 // UpdateEvent ...
 func (c *Client) UpdateEvent(id int64, e EventUpsert) (*Event, error) {
+	return c.UpdateEventContext(context.Background(), id, e)
+}
+
+// UpdateEventContext ...
+func (c *Client) UpdateEventContext(ctx context.Context, id int64, e EventUpsert) (*Event, error) {
 	out := &Event{}
 	e.ID = &id
-	_, err := c.send("PUT", "api/events", nil, e, out)
+	_, err := c.send(ctx, "PUT", "api/events", nil, e, out)
 	return out, err
 }
 
 // DeleteEvent ...
 func (c *Client) DeleteEvent(id int64) error {
+	return c.DeleteEventContext(context.Background(), id)
+}
+
+// DeleteEventContext ...
+func (c *Client) DeleteEventContext(ctx context.Context, id int64) error {
 	r := fmt.Sprintf("api/events/%v", id)
-	return c.delete(r)
+	return c.delete(ctx, r)
 }
@@ -0,0 +1,212 @@
+package agilecrm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultFilterPageSize = 10
+	defaultFilterSortKey  = "-created_time"
+)
+
+// FilterQuery is a dynamic filter over contacts, companies or deals, built
+// by chaining its methods:
+//
+//	q := agilecrm.Filter().
+//		Where("tags", agilecrm.FilterEqual, "vip").
+//		Or("Plan", agilecrm.FilterEqual, "Gold").
+//		Or("Plan", agilecrm.FilterEqual, "Platinum").
+//		Type(agilecrm.TypeContact).
+//		SortBy("-updated_time").
+//		PageSize(100)
+//
+// The left hand side of a rule is either a system field, like "tags",
+// "first_name" or "created_time", or the name of a custom field as it was
+// defined in AgileCRM. Values are sent as strings, except for time.Time
+// values, which are sent as epoch milliseconds the way date rules expect.
+//
+// Every rule added with Where has to match, and at least one of the rules
+// added with Or when there are any.
+type FilterQuery struct {
+	filter  filterJson
+	sort    string
+	perPage int
+	cursor  string
+	err     error
+}
+
+// Filter starts an empty query over contacts, sorted by creation time with
+// the newest first
+func Filter() *FilterQuery {
+	q := &FilterQuery{}
+	q.filter.setType(TypeContact)
+	return q
+}
+
+// filterValue formats a rule's value
+func filterValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case time.Time:
+		return fmt.Sprintf("%v", t.UnixNano()/int64(time.Millisecond))
+	case Time:
+		return fmt.Sprintf("%v", t.Millis())
+	case MilliTime:
+		return fmt.Sprintf("%v", t.Millis())
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// rule builds a rule, checking it was given as many values as its
+// condition needs
+func (q *FilterQuery) rule(lhs string, c FilterCondition, values []interface{}) (filterRule, bool) {
+	if q.err != nil {
+		return filterRule{}, false
+	}
+
+	if lhs == "" {
+		q.err = fmt.Errorf("filter rule needs a field")
+		return filterRule{}, false
+	}
+
+	if n := c.operands(); len(values) != n {
+		q.err = fmt.Errorf("filter condition %v on %v takes %v value(s), got %v", c, lhs, n, len(values))
+		return filterRule{}, false
+	}
+
+	r := filterRule{Left: lhs, Condition: c}
+	if len(values) > 0 {
+		r.Right = filterValue(values[0])
+	}
+	if len(values) > 1 {
+		r.RightNew = filterValue(values[1])
+	}
+	return r, true
+}
+
+// Where adds a rule every result has to match. BETWEEN takes two values,
+// DEFINED and NOT_DEFINED none, every other condition one.
+func (q *FilterQuery) Where(lhs string, c FilterCondition, values ...interface{}) *FilterQuery {
+	if r, ok := q.rule(lhs, c, values); ok {
+		q.filter.addRule(r)
+	}
+	return q
+}
+
+// Or adds a rule to the set of which at least one has to match
+func (q *FilterQuery) Or(lhs string, c FilterCondition, values ...interface{}) *FilterQuery {
+	if r, ok := q.rule(lhs, c, values); ok {
+		q.filter.addOrRule(r)
+	}
+	return q
+}
+
+// Type selects the kind of records to filter
+func (q *FilterQuery) Type(t ContactType) *FilterQuery {
+	q.filter.setType(t)
+	return q
+}
+
+// SortBy sets the field results are sorted by, prefixed with "-" for a
+// descending sort
+func (q *FilterQuery) SortBy(key string) *FilterQuery {
+	q.sort = key
+	return q
+}
+
+// PageSize sets how many results are returned per page (10 when zero)
+func (q *FilterQuery) PageSize(n int) *FilterQuery {
+	q.perPage = n
+	return q
+}
+
+// Cursor sets the page to fetch, as returned by ContactList.Cursor
+func (q *FilterQuery) Cursor(cursor string) *FilterQuery {
+	q.cursor = cursor
+	return q
+}
+
+// Err returns the first invalid rule the query was given, if any
+func (q *FilterQuery) Err() error {
+	return q.err
+}
+
+// pageSize ...
+func (q *FilterQuery) pageSize() int {
+	if q.perPage <= 0 {
+		return defaultFilterPageSize
+	}
+	return q.perPage
+}
+
+// sortKey ...
+func (q *FilterQuery) sortKey() string {
+	if q.sort == "" {
+		return defaultFilterSortKey
+	}
+	return q.sort
+}
+
+// page returns a copy of the query set to another page
+func (q *FilterQuery) page(perPage int, cursor string) *FilterQuery {
+	cp := *q
+	cp.perPage = perPage
+	cp.cursor = cursor
+	return &cp
+}
+
+// FilterContacts returns one page of the contacts or companies matching the
+// query
+func (c *Client) FilterContacts(q *FilterQuery) (ContactList, error) {
+	return c.FilterContactsContext(context.Background(), q)
+}
+
+// FilterContactsContext ...
+func (c *Client) FilterContactsContext(ctx context.Context, q *FilterQuery) (ContactList, error) {
+	if q.filter.ContactType == TypeDeal {
+		return ContactList{}, fmt.Errorf("deal filters have to be run with FilterDeals")
+	}
+
+	out := ContactList{}
+	err := c.dynamicFilter(ctx, q, &out)
+	return out, err
+}
+
+// IterFilterContacts returns an iterator over every contact or company
+// matching the query, starting at the query's cursor and fetching its page
+// size at a time
+func (c *Client) IterFilterContacts(q *FilterQuery) *ContactIterator {
+	it := newContactIterator(q.pageSize(), func(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+		return c.FilterContactsContext(ctx, q.page(perPage, cursor))
+	})
+	it.cursor = q.cursor
+	return it
+}
+
+// FilterDeals returns one page of the deals matching the query, whatever
+// type the query was given
+func (c *Client) FilterDeals(q *FilterQuery) (DealList, error) {
+	return c.FilterDealsContext(context.Background(), q)
+}
+
+// FilterDealsContext ...
+func (c *Client) FilterDealsContext(ctx context.Context, q *FilterQuery) (DealList, error) {
+	cp := *q
+	cp.filter.setType(TypeDeal)
+
+	out := DealList{}
+	err := c.dynamicFilter(ctx, &cp, &out)
+	return out, err
+}
+
+// IterFilterDeals returns an iterator over every deal matching the query
+func (c *Client) IterFilterDeals(q *FilterQuery) *DealIterator {
+	it := newDealIterator(q.pageSize(), func(ctx context.Context, perPage int, cursor string) (DealList, error) {
+		return c.FilterDealsContext(ctx, q.page(perPage, cursor))
+	})
+	it.cursor = q.cursor
+	return it
+}
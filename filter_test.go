@@ -0,0 +1,252 @@
+package agilecrm_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+// recorded is a request seen by a recorder, with its form body parsed
+type recorded struct {
+	method string
+	route  string
+	form   url.Values
+}
+
+// recorder is a transport keeping the requests it forwards
+type recorder struct {
+	mu   sync.Mutex
+	reqs []recorded
+}
+
+// RoundTrip ...
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := recorded{method: req.Method, route: req.URL.Path[strings.Index(req.URL.Path, "/dev/")+5:]}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		rec.form, _ = url.ParseQuery(string(body))
+	}
+
+	r.mu.Lock()
+	r.reqs = append(r.reqs, rec)
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// last returns the latest request
+func (r *recorder) last() recorded {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.reqs) == 0 {
+		return recorded{}
+	}
+	return r.reqs[len(r.reqs)-1]
+}
+
+// count ...
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.reqs)
+}
+
+// wireRule is a filter rule as sent to the API
+type wireRule struct {
+	LHS       string  `json:"LHS"`
+	Condition string  `json:"CONDITION"`
+	RHS       string  `json:"RHS"`
+	RHSNew    *string `json:"RHS_NEW"`
+}
+
+func TestFilterQuery(t *testing.T) {
+	rec := &recorder{}
+	srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.DefaultClient = rec })
+	defer srv.Close()
+
+	from := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+	fromMillis, toMillis := "1704153600000", "1704326400000"
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name  string
+		deals bool
+		query *agilecrm.FilterQuery
+
+		route    string
+		typ      string
+		sort     string
+		pageSize string
+		cursor   string
+		rules    []wireRule
+		orRules  []wireRule
+	}{
+		{
+			name:     "defaults",
+			query:    agilecrm.Filter().Where("tags", agilecrm.FilterEqual, "vip"),
+			route:    "api/filters/filter/dynamic-filter",
+			typ:      "PERSON",
+			sort:     "-created_time",
+			pageSize: "10",
+			rules:    []wireRule{{LHS: "tags", Condition: "EQUALS", RHS: "vip"}},
+		},
+		{
+			name: "time values as millis",
+			query: agilecrm.Filter().
+				Where("created_time", agilecrm.FilterBetween, from, agilecrm.NewTime(to)).
+				Or("updated_time", agilecrm.FilterAfter, agilecrm.NewMilliTime(from)),
+			route:    "api/filters/filter/dynamic-filter",
+			typ:      "PERSON",
+			sort:     "-created_time",
+			pageSize: "10",
+			rules:    []wireRule{{LHS: "created_time", Condition: "BETWEEN", RHS: fromMillis, RHSNew: strPtr(toMillis)}},
+			orRules:  []wireRule{{LHS: "updated_time", Condition: "AFTER", RHS: fromMillis}},
+		},
+		{
+			name:     "defined takes no value",
+			query:    agilecrm.Filter().Where("Plan", agilecrm.FilterDefined).Type(agilecrm.TypeCompany),
+			route:    "api/filters/filter/dynamic-filter",
+			typ:      "COMPANY",
+			sort:     "-created_time",
+			pageSize: "10",
+			rules:    []wireRule{{LHS: "Plan", Condition: "DEFINED"}},
+		},
+		{
+			name: "sort, page size and cursor",
+			query: agilecrm.Filter().Where("lead_score", agilecrm.FilterGreaterThan, 10).
+				SortBy("-updated_time").PageSize(50).Cursor("abc"),
+			route:    "api/filters/filter/dynamic-filter",
+			typ:      "PERSON",
+			sort:     "-updated_time",
+			pageSize: "50",
+			cursor:   "abc",
+			rules:    []wireRule{{LHS: "lead_score", Condition: "IS_GREATER_THAN", RHS: "10"}},
+		},
+		{
+			name:     "deals",
+			deals:    true,
+			query:    agilecrm.Filter().Where("milestone", agilecrm.FilterEqual, "Won"),
+			route:    "api/opportunity/based",
+			typ:      "opportunity",
+			sort:     "-created_time",
+			pageSize: "10",
+			rules:    []wireRule{{LHS: "milestone", Condition: "EQUALS", RHS: "Won"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.query.Err(); err != nil {
+				t.Fatalf("invalid query; %v", err)
+			}
+
+			var err error
+			if tt.deals {
+				_, err = cl.FilterDeals(tt.query)
+			} else {
+				_, err = cl.FilterContacts(tt.query)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+
+			got := rec.last()
+			if got.method != "POST" || got.route != tt.route {
+				t.Errorf("sent %v %v, want POST %v", got.method, got.route, tt.route)
+			}
+			for key, want := range map[string]string{
+				"global_sort_key": tt.sort,
+				"page_size":       tt.pageSize,
+				"cursor":          tt.cursor,
+			} {
+				if v := got.form.Get(key); v != want {
+					t.Errorf("%v = %q, want %q", key, v, want)
+				}
+			}
+
+			fj := struct {
+				Rules       []wireRule `json:"rules"`
+				OrRules     []wireRule `json:"or_rules"`
+				ContactType string     `json:"contact_type"`
+			}{}
+			if err := json.Unmarshal([]byte(got.form.Get("filterJson")), &fj); err != nil {
+				t.Fatalf("invalid filterJson; %v", err)
+			}
+			if fj.ContactType != tt.typ {
+				t.Errorf("contact_type = %q, want %q", fj.ContactType, tt.typ)
+			}
+			compareRules(t, "rules", fj.Rules, tt.rules)
+			compareRules(t, "or_rules", fj.OrRules, tt.orRules)
+		})
+	}
+}
+
+// compareRules ...
+func compareRules(t *testing.T, name string, got, want []wireRule) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("%v = %+v, want %+v", name, got, want)
+		return
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.LHS != w.LHS || g.Condition != w.Condition || g.RHS != w.RHS ||
+			(g.RHSNew == nil) != (w.RHSNew == nil) ||
+			g.RHSNew != nil && *g.RHSNew != *w.RHSNew {
+			t.Errorf("%v[%v] = %+v, want %+v", name, i, g, w)
+		}
+	}
+}
+
+func TestFilterQueryInvalid(t *testing.T) {
+	rec := &recorder{}
+	srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.DefaultClient = rec })
+	defer srv.Close()
+
+	tests := []struct {
+		name  string
+		query *agilecrm.FilterQuery
+	}{
+		{"between with one value", agilecrm.Filter().Where("created_time", agilecrm.FilterBetween, time.Now())},
+		{"between with three values", agilecrm.Filter().Where("lead_score", agilecrm.FilterBetween, 1, 2, 3)},
+		{"defined with a value", agilecrm.Filter().Where("Plan", agilecrm.FilterDefined, "Gold")},
+		{"not defined with a value", agilecrm.Filter().Or("Plan", agilecrm.FilterNotDefined, "Gold")},
+		{"equals without a value", agilecrm.Filter().Where("tags", agilecrm.FilterEqual)},
+		{"no field", agilecrm.Filter().Where("", agilecrm.FilterEqual, "vip")},
+		{"first error kept", agilecrm.Filter().Where("tags", agilecrm.FilterEqual).Where("tags", agilecrm.FilterEqual, "vip")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.query.Err() == nil {
+				t.Fatal("expected the query to be invalid")
+			}
+
+			before := rec.count()
+			if _, err := cl.FilterContacts(tt.query); err != tt.query.Err() {
+				t.Errorf("error = %v, want the query's", err)
+			}
+			if n := rec.count() - before; n != 0 {
+				t.Errorf("%v requests sent for an invalid query", n)
+			}
+		})
+	}
+
+	if _, err := cl.FilterContacts(agilecrm.Filter().Type(agilecrm.TypeDeal)); err == nil {
+		t.Error("deal filters should need FilterDeals")
+	}
+}
@@ -0,0 +1,265 @@
+package agilecrm
+
+import (
+	"context"
+	"errors"
+)
+
+// defaultIterPageSize is used by iterators created with a page size of zero
+const defaultIterPageSize = 25
+
+// contactPager fetches one page of a contact listing
+type contactPager func(ctx context.Context, perPage int, cursor string) (ContactList, error)
+
+// ContactIterator walks every page of a contact or company listing:
+//
+//	it := cl.IterContacts(100)
+//	for it.Next(ctx) {
+//		c := it.Contact()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//
+// Stopping early is done by not calling Next anymore; Cursor can be used to
+// resume the listing later with the paged methods.
+type ContactIterator struct {
+	fetch   contactPager
+	perPage int
+	cursor  string
+	page    ContactList
+	idx     int
+	cur     *Contact
+	last    bool
+	err     error
+}
+
+// newContactIterator ...
+func newContactIterator(perPage int, fetch contactPager) *ContactIterator {
+	if perPage <= 0 {
+		perPage = defaultIterPageSize
+	}
+	return &ContactIterator{fetch: fetch, perPage: perPage}
+}
+
+// Next advances to the next contact, fetching a new page when needed. It
+// returns false once every page was read or an error occurred.
+func (it *ContactIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.idx >= len(it.page) {
+		if it.last {
+			it.cur = nil
+			return false
+		}
+
+		page, err := it.fetch(ctx, it.perPage, it.cursor)
+		if errors.Is(err, ErrNoContacts) {
+			page, err = ContactList{}, nil
+		}
+		if err != nil {
+			it.err = err
+			it.cur = nil
+			return false
+		}
+
+		next := page.Cursor()
+		it.last = next == "" || next == it.cursor
+		it.cursor = next
+		it.page = page
+		it.idx = 0
+	}
+
+	it.cur = it.page[it.idx]
+	it.idx++
+	return true
+}
+
+// Contact returns the current contact
+func (it *ContactIterator) Contact() *Contact {
+	return it.cur
+}
+
+// Cursor returns the cursor of the next page, empty after the last one
+func (it *ContactIterator) Cursor() string {
+	if it.last {
+		return ""
+	}
+	return it.cursor
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ContactIterator) Err() error {
+	return it.err
+}
+
+// dealPager fetches one page of a deal listing
+type dealPager func(ctx context.Context, perPage int, cursor string) (DealList, error)
+
+// DealIterator walks every page of a deal listing, the same way
+// ContactIterator does for contacts.
+type DealIterator struct {
+	fetch   dealPager
+	perPage int
+	cursor  string
+	page    DealList
+	idx     int
+	cur     *Deal
+	last    bool
+	err     error
+}
+
+// newDealIterator ...
+func newDealIterator(perPage int, fetch dealPager) *DealIterator {
+	if perPage <= 0 {
+		perPage = defaultIterPageSize
+	}
+	return &DealIterator{fetch: fetch, perPage: perPage}
+}
+
+// Next advances to the next deal, fetching a new page when needed. It
+// returns false once every page was read or an error occurred.
+func (it *DealIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.idx >= len(it.page) {
+		if it.last {
+			it.cur = nil
+			return false
+		}
+
+		page, err := it.fetch(ctx, it.perPage, it.cursor)
+		if errors.Is(err, ErrNoContacts) {
+			page, err = DealList{}, nil
+		}
+		if err != nil {
+			it.err = err
+			it.cur = nil
+			return false
+		}
+
+		next := page.Cursor()
+		it.last = next == "" || next == it.cursor
+		it.cursor = next
+		it.page = page
+		it.idx = 0
+	}
+
+	it.cur = &it.page[it.idx]
+	it.idx++
+	return true
+}
+
+// Deal returns the current deal
+func (it *DealIterator) Deal() *Deal {
+	return it.cur
+}
+
+// Cursor returns the cursor of the next page, empty after the last one
+func (it *DealIterator) Cursor() string {
+	if it.last {
+		return ""
+	}
+	return it.cursor
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *DealIterator) Err() error {
+	return it.err
+}
+
+// IterContacts returns an iterator over every contact, fetched perPage at a
+// time (25 when zero)
+func (c *Client) IterContacts(perPage int) *ContactIterator {
+	return newContactIterator(perPage, c.ListContactsContext)
+}
+
+// IterCompanies returns an iterator over every company
+func (c *Client) IterCompanies(perPage int) *ContactIterator {
+	return newContactIterator(perPage, c.ListCompaniesPageContext)
+}
+
+// IterCompanyContacts returns an iterator over the people linked to a
+// company
+func (c *Client) IterCompanyContacts(companyID int64, perPage int) *ContactIterator {
+	return newContactIterator(perPage, func(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+		return c.ListCompanyContactsContext(ctx, companyID, perPage, cursor)
+	})
+}
+
+// IterContactsByTag returns an iterator over the contacts with the tag
+func (c *Client) IterContactsByTag(tag string, perPage int) *ContactIterator {
+	return newContactIterator(perPage, func(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+		return c.findByTag(ctx, TypeContact, tag, perPage, cursor)
+	})
+}
+
+// IterCompaniesByTag returns an iterator over the companies with the tag
+func (c *Client) IterCompaniesByTag(tag string, perPage int) *ContactIterator {
+	return newContactIterator(perPage, func(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+		return c.findByTag(ctx, TypeCompany, tag, perPage, cursor)
+	})
+}
+
+// IterDeals returns an iterator over every deal
+func (c *Client) IterDeals(perPage int) *DealIterator {
+	return newDealIterator(perPage, c.ListDealsContext)
+}
+
+// IterDealsByTag returns an iterator over the deals with the tag
+func (c *Client) IterDealsByTag(tag string, perPage int) *DealIterator {
+	if perPage <= 0 {
+		perPage = defaultIterPageSize
+	}
+	return c.IterFilterDeals(Filter().Where("tags", FilterEqual, tag).PageSize(perPage))
+}
+
+// IterContactDeals returns an iterator over the deals of a contact with the
+// status
+func (c *Client) IterContactDeals(contactID int64, status DealStatus, perPage int) *DealIterator {
+	return newDealIterator(perPage, c.dealsByPager(contactDealsRoute(contactID), status))
+}
+
+// IterMyDeals returns an iterator over the deals with the status owned by
+// the user the client is authenticated as
+func (c *Client) IterMyDeals(status DealStatus, perPage int) *DealIterator {
+	return newDealIterator(perPage, c.dealsByPager(myDealsRoute, status))
+}
+
+// dealsByPager fetches the pages of a listDealsBy listing for an iterator,
+// reading on past pages left empty by the status since an empty page ends
+// the iteration
+func (c *Client) dealsByPager(route string, status DealStatus) dealPager {
+	// pipelines are loaded once for the whole walk
+	var pl PipelineList
+	return func(ctx context.Context, perPage int, cursor string) (DealList, error) {
+		for {
+			opts := DealOptions{Status: status, PerPage: perPage, Cursor: cursor}
+			dl, next, err := c.listDealsBy(ctx, route, opts, &pl)
+			if err != nil || len(dl) > 0 || next == "" {
+				return dl, err
+			}
+			cursor = next
+		}
+	}
+}
+
+// IterSearchContacts returns an iterator over the results of a keyword
+// search for contacts or companies, depending on typ
+func (c *Client) IterSearchContacts(query string, typ ContactType, perPage int) *ContactIterator {
+	return newContactIterator(perPage, func(ctx context.Context, perPage int, cursor string) (ContactList, error) {
+		opts := SearchOptions{Type: typ, PerPage: perPage, Cursor: cursor}
+		return c.SearchContactsPageContext(ctx, query, opts)
+	})
+}
+
+// IterSearchDeals returns an iterator over the results of a keyword search
+// for deals
+func (c *Client) IterSearchDeals(query string, perPage int) *DealIterator {
+	return newDealIterator(perPage, func(ctx context.Context, perPage int, cursor string) (DealList, error) {
+		return c.SearchDealsContext(ctx, query, perPage, cursor)
+	})
+}
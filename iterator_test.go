@@ -0,0 +1,130 @@
+package agilecrm_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestIterContacts(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   int
+		perPage  int
+		requests int
+	}{
+		{name: "no contacts is the end", stored: 0, perPage: 10, requests: 1},
+		{name: "one partial page", stored: 3, perPage: 10, requests: 1},
+		{name: "exact pages", stored: 20, perPage: 10, requests: 2},
+		{name: "last page partial", stored: 25, perPage: 10, requests: 3},
+		{name: "default page size", stored: 30, perPage: 0, requests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+			for i := 0; i < tt.stored; i++ {
+				srv.AddContact(agilecrm.Contact{})
+			}
+
+			before := srv.Requests()
+			seen := map[agilecrm.ID]bool{}
+			it := cl.IterContacts(tt.perPage)
+			for it.Next(context.Background()) {
+				id := it.Contact().ID
+				if seen[id] {
+					t.Fatalf("contact %v returned twice", id)
+				}
+				seen[id] = true
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+			if len(seen) != tt.stored {
+				t.Errorf("walked %v contacts, want %v", len(seen), tt.stored)
+			}
+			if n := srv.Requests() - before; n != tt.requests {
+				t.Errorf("requests = %v, want %v", n, tt.requests)
+			}
+			if it.Cursor() != "" {
+				t.Errorf("cursor = %q after the last page", it.Cursor())
+			}
+
+			// a finished iterator stays finished
+			if it.Next(context.Background()) || it.Contact() != nil {
+				t.Error("Next returned a contact after the end")
+			}
+		})
+	}
+}
+
+func TestIterContactsResume(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+	for i := 0; i < 25; i++ {
+		srv.AddContact(agilecrm.Contact{})
+	}
+
+	seen := map[agilecrm.ID]bool{}
+	it := cl.IterContacts(10)
+	for i := 0; i < 10 && it.Next(context.Background()); i++ {
+		seen[it.Contact().ID] = true
+	}
+
+	// the rest of the listing is fetched with the paged method
+	cursor := it.Cursor()
+	if cursor == "" {
+		t.Fatal("no cursor to resume from")
+	}
+	for cursor != "" {
+		page, err := cl.ListContacts(10, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ctc := range page {
+			if seen[ctc.ID] {
+				t.Fatalf("contact %v returned again after resuming", ctc.ID)
+			}
+			seen[ctc.ID] = true
+		}
+		cursor = page.Cursor()
+	}
+
+	if len(seen) != 25 {
+		t.Errorf("walked %v contacts, want 25", len(seen))
+	}
+}
+
+func TestIterDealsError(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+	for i := 0; i < 15; i++ {
+		srv.AddDeal(agilecrm.Deal{Name: fmt.Sprintf("deal %v", i), Milestone: "New"})
+	}
+
+	it := cl.IterDeals(10)
+	n := 0
+	for it.Next(context.Background()) {
+		n++
+		if n == 10 {
+			// the second page fails
+			srv.Inject(agilecrmtest.Fault{Route: "api/opportunity", Status: http.StatusInternalServerError})
+		}
+	}
+
+	if n != 10 {
+		t.Errorf("walked %v deals before the error, want 10", n)
+	}
+	if it.Err() == nil {
+		t.Fatal("expected the failed page to be reported")
+	}
+	if it.Next(context.Background()) || it.Deal() != nil {
+		t.Error("Next returned a deal after the error")
+	}
+}
@@ -0,0 +1,403 @@
+package agilecrm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DuplicateGroup is a set of contacts that look like the same person
+type DuplicateGroup struct {
+	// Reason is what the contacts have in common: "email", "phone" or
+	// "name+company"
+	Reason string
+
+	// Key is the normalized value they share
+	Key string
+
+	Contacts ContactList
+}
+
+// FindDuplicateContacts walks every contact and groups the ones sharing an
+// email, a phone number, or a name and company, ignoring case, spacing and
+// phone formatting. A contact can be part of several groups.
+func (c *Client) FindDuplicateContacts() ([]DuplicateGroup, error) {
+	return c.FindDuplicateContactsContext(context.Background())
+}
+
+// FindDuplicateContactsContext ...
+func (c *Client) FindDuplicateContactsContext(ctx context.Context) ([]DuplicateGroup, error) {
+	type groupKey struct{ reason, key string }
+	groups := map[groupKey]ContactList{}
+	add := func(reason, key string, ctc *Contact) {
+		if key == "" {
+			return
+		}
+		k := groupKey{reason, key}
+		for _, cur := range groups[k] {
+			if cur.ID == ctc.ID {
+				return
+			}
+		}
+		groups[k] = append(groups[k], ctc)
+	}
+
+	it := c.IterContacts(100)
+	for it.Next(ctx) {
+		ctc := it.Contact()
+		for _, p := range ctc.Emails() {
+			add("email", normalizeEmail(p.Value), ctc)
+		}
+		for _, p := range ctc.Phones() {
+			add("phone", normalizePhone(p.Value), ctc)
+		}
+
+		name, company := normalizeName(ctc.Name()), normalizeName(ctc.CompanyName())
+		if name != "" && company != "" {
+			add("name+company", name+"|"+company, ctc)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	out := []DuplicateGroup{}
+	for k, cl := range groups {
+		if len(cl) > 1 {
+			out = append(out, DuplicateGroup{Reason: k.reason, Key: k.key, Contacts: cl})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Reason != out[j].Reason {
+			return out[i].Reason < out[j].Reason
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+// normalizePhone keeps the digits of a phone number, ignoring numbers too
+// short to tell people apart
+func normalizePhone(phone string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+
+	if len(digits) < 7 {
+		return ""
+	}
+	return digits
+}
+
+// normalizeName lowercases a name and collapses its whitespace
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// MergeReport describes a merge of duplicate contacts into a primary one.
+// After a dry run it lists what the merge would do.
+type MergeReport struct {
+	DryRun bool
+
+	// Primary is the primary contact after the merge
+	Primary *Contact
+
+	// Duplicates are the contacts merged into the primary, as they were
+	// before being deleted
+	Duplicates ContactList
+
+	// Tags and Properties are what was added to the primary
+	Tags       []string
+	Properties PropertyList
+
+	// Notes were recreated on the primary; Tasks, Deals and Documents were
+	// linked to it instead of the duplicates
+	Notes     NoteList
+	Tasks     TaskList
+	Deals     DealList
+	Documents DocumentList
+}
+
+// MergeContacts merges the duplicates into the primary contact and deletes
+// them. Tags are added to the primary, as are properties it has no value
+// for and any extra emails, phones and websites. Notes are recreated on the
+// primary, while tasks, deals and documents are linked to it in place of the
+// duplicates.
+//
+// The primary gets the tags and properties first, so a failed update leaves
+// the duplicates untouched. Only the duplicates' emails wait until they are
+// deleted, since the API won't give an email to two contacts. The report
+// lists everything that was moved, which can be used to recover if a later
+// step fails.
+func (c *Client) MergeContacts(primaryID int64, dupIDs ...int64) (*MergeReport, error) {
+	return c.MergeContactsContext(context.Background(), primaryID, dupIDs...)
+}
+
+// MergeContactsContext ...
+func (c *Client) MergeContactsContext(ctx context.Context, primaryID int64, dupIDs ...int64) (*MergeReport, error) {
+	return c.mergeContacts(ctx, false, primaryID, dupIDs)
+}
+
+// MergeContactsDryRun reports what MergeContacts would do, without changing
+// anything
+func (c *Client) MergeContactsDryRun(primaryID int64, dupIDs ...int64) (*MergeReport, error) {
+	return c.MergeContactsDryRunContext(context.Background(), primaryID, dupIDs...)
+}
+
+// MergeContactsDryRunContext ...
+func (c *Client) MergeContactsDryRunContext(ctx context.Context, primaryID int64, dupIDs ...int64) (*MergeReport, error) {
+	return c.mergeContacts(ctx, true, primaryID, dupIDs)
+}
+
+// mergeContacts ...
+func (c *Client) mergeContacts(ctx context.Context, dryRun bool, primaryID int64, dupIDs []int64) (*MergeReport, error) {
+	ids := []int64{}
+	seen := map[int64]bool{primaryID: true}
+	for _, id := range dupIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("merge needs at least one contact other than the primary")
+	}
+
+	primary, err := c.FindContactByIdContext(ctx, int(primaryID))
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &MergeReport{DryRun: dryRun, Duplicates: ContactList{}}
+	in := Contact{}
+	for _, id := range ids {
+		dup, err := c.FindContactByIdContext(ctx, int(id))
+		if err != nil {
+			return nil, err
+		}
+		rep.Duplicates = append(rep.Duplicates, dup)
+		in.Properties = append(in.Properties, dup.Properties...)
+		for _, t := range dup.Tags {
+			if !containsString(primary.Tags, t) && !containsString(in.Tags, t) {
+				in.Tags = append(in.Tags, t)
+			}
+		}
+
+		if err := c.collectLinked(ctx, rep, id); err != nil {
+			return nil, err
+		}
+	}
+
+	rep.Tags = in.Tags
+	merged := *primary
+	merged.Properties = append(PropertyList{}, primary.Properties...)
+	rep.Properties = mergeUnion.merge(&merged.Properties, in.Properties)
+
+	if dryRun {
+		merged.Tags = append(append([]string{}, primary.Tags...), in.Tags...)
+		rep.Primary = &merged
+		return rep, nil
+	}
+
+	// the emails still belong to the duplicates until they are deleted
+	first, emails := Contact{Tags: in.Tags}, Contact{}
+	for _, p := range in.Properties {
+		if strings.EqualFold(p.Name, PropEmail) {
+			emails.Properties = append(emails.Properties, p)
+		} else {
+			first.Properties = append(first.Properties, p)
+		}
+	}
+
+	rep.Primary, err = c.mergeContact(ctx, primary, first, mergeUnion)
+	if err != nil {
+		return rep, err
+	}
+
+	if err := c.moveLinked(ctx, rep, primaryID); err != nil {
+		return rep, err
+	}
+
+	for _, id := range ids {
+		if err := c.DeleteContactContext(ctx, int(id)); err != nil {
+			return rep, err
+		}
+	}
+
+	rep.Primary, err = c.mergeContact(ctx, rep.Primary, emails, mergeUnion)
+	return rep, err
+}
+
+// collectLinked adds the notes, tasks, deals and documents of a duplicate
+// to the report, skipping the ones linked to an earlier duplicate
+func (c *Client) collectLinked(ctx context.Context, rep *MergeReport, id int64) error {
+	notes, err := c.GetContactNotesContext(ctx, int(id))
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		if !containsID(len(rep.Notes), func(i int) ID { return rep.Notes[i].ID }, n.ID) {
+			rep.Notes = append(rep.Notes, n)
+		}
+	}
+
+	tasks, err := c.GetContactTasksContext(ctx, int(id))
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if !containsID(len(rep.Tasks), func(i int) ID { return rep.Tasks[i].ID }, t.ID) {
+			rep.Tasks = append(rep.Tasks, t)
+		}
+	}
+
+	it := c.IterContactDeals(id, DealsAll, 0)
+	for it.Next(ctx) {
+		d := *it.Deal()
+		if !containsID(len(rep.Deals), func(i int) ID { return rep.Deals[i].ID }, d.ID) {
+			rep.Deals = append(rep.Deals, d)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	docs, err := c.GetContactDocumentsContext(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, d := range docs {
+		if !containsID(len(rep.Documents), func(i int) ID { return rep.Documents[i].ID }, d.ID) {
+			rep.Documents = append(rep.Documents, d)
+		}
+	}
+	return nil
+}
+
+// containsID reports whether any of the n IDs returned by at is id
+func containsID(n int, at func(int) ID, id ID) bool {
+	for i := 0; i < n; i++ {
+		if at(i) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// moveLinked links what the report collected to the primary instead of the
+// duplicates
+func (c *Client) moveLinked(ctx context.Context, rep *MergeReport, primaryID int64) error {
+	dups := map[string]bool{}
+	for _, d := range rep.Duplicates {
+		dups[d.ID.String()] = true
+	}
+	relink := func(ids []string) []string {
+		out := []string{ID(primaryID).String()}
+		for _, id := range ids {
+			if !dups[id] && !containsString(out, id) {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+
+	// notes can't be moved, so they are recreated on the primary and
+	// removed from the duplicates
+	for i, n := range rep.Notes {
+		from := n.ContactIDs
+		if len(from) == 0 {
+			from = contactIDs(n.Contacts)
+		}
+
+		moved, err := c.CreateNoteContext(ctx, Note{
+			Subject:     n.Subject,
+			Description: n.Description,
+			ContactIDs:  relink(from),
+			DealIDs:     n.DealIDs,
+		})
+		if err != nil {
+			return err
+		}
+
+		// one removal is enough, the other duplicates are deleted anyway
+		for _, id := range from {
+			if dups[id] {
+				if err := c.deleteContactNote(ctx, id, n.ID); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		rep.Notes[i] = moved
+	}
+
+	for i, t := range rep.Tasks {
+		patch := map[string]interface{}{
+			"id":       t.ID,
+			"contacts": relink(contactIDs(t.Contacts)),
+		}
+		out := Task{}
+		if _, err := c.send(ctx, "PUT", "api/tasks/partial-update", nil, patch, &out); err != nil {
+			return err
+		}
+		rep.Tasks[i] = out
+	}
+
+	for i, d := range rep.Deals {
+		from := d.ContactIds
+		if len(from) == 0 {
+			from = contactIDs(d.Contacts)
+		}
+		out, err := c.UpdateDealContext(ctx, int64(d.ID), Deal{ContactIds: relink(from)})
+		if err != nil {
+			return err
+		}
+		rep.Deals[i] = *out
+	}
+
+	for i, d := range rep.Documents {
+		from := d.ContactIds
+		if len(from) == 0 {
+			from = contactIDs(d.Contacts)
+		}
+		out, err := c.UpdateDocumentContext(ctx, int64(d.ID), UpsertDoc{
+			Extension:   d.Extension,
+			DocType:     d.DocType,
+			Name:        d.Name,
+			URL:         d.URL,
+			Size:        int(d.Size),
+			NetworkType: d.NetworkType,
+			ContactIds:  relink(from),
+			DealIds:     d.DealIds,
+		})
+		if err != nil {
+			return err
+		}
+		rep.Documents[i] = *out
+	}
+
+	return nil
+}
+
+// contactIDs ...
+func contactIDs(cl ContactList) []string {
+	out := []string{}
+	for _, ctc := range cl {
+		if ctc != nil {
+			out = append(out, ctc.ID.String())
+		}
+	}
+	return out
+}
+
+// deleteContactNote removes a note from a contact given the contact's ID as
+// a string, the way notes list them
+func (c *Client) deleteContactNote(ctx context.Context, contactID string, noteID ID) error {
+	r := fmt.Sprintf("api/contacts/%v/notes/%v", contactID, noteID)
+	return c.delete(ctx, r)
+}
@@ -0,0 +1,239 @@
+package agilecrm_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestMergeContacts(t *testing.T) {
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{name: "dry run", dryRun: true},
+		{name: "merge", dryRun: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+
+			primary := newContact(t, cl, "primary@example.com")
+			if _, err := cl.UpdateContactTags(int64(primary.ID), []string{"customer"}); err != nil {
+				t.Fatal(err)
+			}
+
+			in := agilecrm.Contact{Tags: []string{"customer", "vip"}}
+			in.SetEmail("", "dup@example.com")
+			in.AddPhone("", "+1 555 0100")
+			dup, err := cl.CreateContact(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dupIDs := []string{dup.ID.String()}
+
+			note, err := cl.CreateNote(agilecrm.Note{Subject: "call back", ContactIDs: dupIDs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			task, err := cl.CreateTask(agilecrm.TaskCreate{Subject: "follow up", Type: agilecrm.TaskCall, PriorityType: agilecrm.TaskPriorityNorm, ContactIDs: dupIDs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			deal, err := cl.CreateDeal(agilecrm.Deal{Name: "renewal", Milestone: "New", ContactIds: dupIDs})
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := cl.CreateDocument(agilecrm.UpsertDoc{Name: "contract", URL: "https://example.com/c.pdf", NetworkType: "GOOGLE", ContactIds: dupIDs})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			merge := cl.MergeContacts
+			if tt.dryRun {
+				merge = cl.MergeContactsDryRun
+			}
+			rep, err := merge(int64(primary.ID), int64(dup.ID), int64(primary.ID))
+			if err != nil {
+				t.Fatalf("merge failed; %v", err)
+			}
+
+			if rep.DryRun != tt.dryRun {
+				t.Errorf("report dry run = %v", rep.DryRun)
+			}
+			if len(rep.Duplicates) != 1 || rep.Duplicates[0].ID != dup.ID {
+				t.Errorf("duplicates = %v, want only %v", rep.Duplicates, dup.ID)
+			}
+			if len(rep.Tags) != 1 || rep.Tags[0] != "vip" {
+				t.Errorf("tags added = %v, want [vip]", rep.Tags)
+			}
+			if len(rep.Notes) != 1 || len(rep.Tasks) != 1 || len(rep.Deals) != 1 || len(rep.Documents) != 1 {
+				t.Errorf("moved %v notes, %v tasks, %v deals, %v documents, want one of each",
+					len(rep.Notes), len(rep.Tasks), len(rep.Deals), len(rep.Documents))
+			}
+			if got := rep.Primary.Emails(); len(got) != 2 {
+				t.Errorf("primary emails = %v, want both", got)
+			}
+
+			// where the linked records point after the call
+			owner, linked := dup.ID.String(), 0
+			if !tt.dryRun {
+				owner, linked = primary.ID.String(), 1
+			}
+
+			_, err = cl.FindContactById(int(dup.ID))
+			if tt.dryRun && err != nil {
+				t.Errorf("dry run removed the duplicate; %v", err)
+			}
+			if !tt.dryRun && !errors.Is(err, agilecrm.ErrNoSuchContact) {
+				t.Errorf("duplicate still exists; %v", err)
+			}
+
+			stored, err := cl.FindContactById(int(primary.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantEmails, wantTags := 1, 1
+			if !tt.dryRun {
+				wantEmails, wantTags = 2, 2
+			}
+			if got := stored.Emails(); len(got) != wantEmails {
+				t.Errorf("stored primary emails = %v, want %v", got, wantEmails)
+			}
+			if len(stored.Tags) != wantTags {
+				t.Errorf("stored primary tags = %v, want %v", stored.Tags, wantTags)
+			}
+			if !tt.dryRun && stored.Phone() == "" {
+				t.Error("primary didn't get the duplicate's phone")
+			}
+
+			d, err := cl.FindDealByID(int(deal.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(d.ContactIds) != 1 || d.ContactIds[0] != owner {
+				t.Errorf("deal contacts = %v, want [%v]", d.ContactIds, owner)
+			}
+
+			notes, err := cl.GetContactNotes(int(primary.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(notes) != linked || linked > 0 && notes[0].Subject != note.Subject {
+				t.Errorf("primary has %v notes, want %v", len(notes), linked)
+			}
+
+			tasks, err := cl.GetContactTasks(int(primary.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tasks) != linked || linked > 0 && tasks[0].ID != task.ID {
+				t.Errorf("primary has %v tasks, want %v", len(tasks), linked)
+			}
+
+			docs, err := cl.GetContactDocuments(int64(primary.ID))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) != linked || linked > 0 && docs[0].ID != doc.ID {
+				t.Errorf("primary has %v documents, want %v", len(docs), linked)
+			}
+		})
+	}
+}
+
+func TestMergeContactsPrimaryUpdateFails(t *testing.T) {
+	tests := []struct {
+		name  string
+		route string
+	}{
+		{"properties", "api/contacts/edit-properties"},
+		{"tags", "api/contacts/edit/tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+
+			primary := newContact(t, cl, "primary@example.com")
+			in := agilecrm.Contact{Tags: []string{"vip"}}
+			in.SetEmail("", "dup@example.com")
+			in.AddPhone("", "+1 555 0100")
+			dup, err := cl.CreateContact(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deal, err := cl.CreateDeal(agilecrm.Deal{Name: "renewal", Milestone: "New", ContactIds: []string{dup.ID.String()}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv.Inject(agilecrmtest.Fault{Method: "PUT", Route: tt.route, Status: http.StatusInternalServerError})
+			if _, err := cl.MergeContacts(int64(primary.ID), int64(dup.ID)); err == nil {
+				t.Fatal("expected the failed update to fail the merge")
+			}
+
+			// nothing is lost: the duplicate and its links are still there
+			stored, ok := srv.Contact(dup.ID)
+			if !ok {
+				t.Fatal("duplicate deleted after the primary failed to update")
+			}
+			if stored.Email() != "dup@example.com" || stored.Phone() == "" || len(stored.Tags) != 1 {
+				t.Errorf("duplicate changed to %+v", stored)
+			}
+			if d, _ := srv.Deal(deal.ID); len(d.ContactIds) != 1 || d.ContactIds[0] != dup.ID.String() {
+				t.Errorf("deal relinked to %v", d.ContactIds)
+			}
+		})
+	}
+}
+
+func TestMergeContactsNeedsDuplicates(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	primary := newContact(t, cl, "alone@example.com")
+	if _, err := cl.MergeContacts(int64(primary.ID), int64(primary.ID)); err == nil {
+		t.Fatal("merging a contact into itself should fail")
+	}
+}
+
+func TestFindDuplicateContacts(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	add := func(email, phone string) {
+		in := agilecrm.Contact{}
+		in.SetEmail("", email)
+		if phone != "" {
+			in.AddPhone("", phone)
+		}
+		srv.AddContact(in)
+	}
+	add("same@example.com", "")
+	add(" SAME@example.com", "")
+	add("a@example.com", "(555) 010-0200")
+	add("b@example.com", "555.010.0200")
+	add("unique@example.com", "555 999 0000")
+
+	groups, err := cl.FindDuplicateContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"email": "same@example.com", "phone": "5550100200"}
+	if len(groups) != len(want) {
+		t.Fatalf("got %v groups, want %v", len(groups), len(want))
+	}
+	for _, g := range groups {
+		if want[g.Reason] != g.Key || len(g.Contacts) != 2 {
+			t.Errorf("group %v %q has %v contacts", g.Reason, g.Key, len(g.Contacts))
+		}
+	}
+}
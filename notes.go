@@ -1,6 +1,7 @@
 package agilecrm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -8,12 +9,12 @@ import (
 )
 
 type Note struct {
-	ID          int64    `json:"id,omitempty"`
+	ID          ID       `json:"id,omitempty"`
 	Subject     string   `json:"subject"`
 	Description string   `json:"description"`
 	ContactIDs  []string `json:"contact_ids,omitempty"`
 	DealIDs     []string `json:"deal_ids,omitempty"`
-	CreatedTime int      `json:"created_time,omitempty"`
+	CreatedTime Time     `json:"created_time,omitempty"`
 	EntityType  string   `json:"entity_type,omitempty"`
 
 	// Count is only used by the API when getting the notes for a contact
@@ -25,8 +26,13 @@ type NoteList []*Note
 
 // CreateNote ...
 func (c *Client) CreateNote(in Note) (*Note, error) {
+	return c.CreateNoteContext(context.Background(), in)
+}
+
+// CreateNoteContext ...
+func (c *Client) CreateNoteContext(ctx context.Context, in Note) (*Note, error) {
 	in.Count = nil
-	_, err := c.send("POST", "api/notes", nil, in, &in)
+	_, err := c.send(ctx, "POST", "api/notes", nil, in, &in)
 	if err != nil {
 		return nil, err
 	}
@@ -35,6 +41,11 @@ func (c *Client) CreateNote(in Note) (*Note, error) {
 
 // AddNoteToContact ...
 func (c *Client) AddNoteToContact(email string, in Note) (*Note, error) {
+	return c.AddNoteToContactContext(context.Background(), email, in)
+}
+
+// AddNoteToContactContext ...
+func (c *Client) AddNoteToContactContext(ctx context.Context, email string, in Note) (*Note, error) {
 	in.Count = nil
 
 	bits, err := json.Marshal(in)
@@ -47,7 +58,7 @@ func (c *Client) AddNoteToContact(email string, in Note) (*Note, error) {
 	vals.Add("note", string(bits))
 	q := vals.Encode()
 
-	req, err := c.postForm("POST", "api/contacts/email/note/add", strings.NewReader(q), nil)
+	req, err := c.postForm(ctx, "POST", "api/contacts/email/note/add", strings.NewReader(q), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -62,27 +73,42 @@ func (c *Client) AddNoteToContact(email string, in Note) (*Note, error) {
 
 // GetContactNotes ...
 func (c *Client) GetContactNotes(id int) (NoteList, error) {
+	return c.GetContactNotesContext(context.Background(), id)
+}
+
+// GetContactNotesContext ...
+func (c *Client) GetContactNotesContext(ctx context.Context, id int) (NoteList, error) {
 	r := fmt.Sprintf("api/contacts/%v/notes", id)
 	out := NoteList{}
 
-	_, err := c.get("GET", r, nil, nil, &out)
+	_, err := c.get(ctx, "GET", r, nil, nil, &out)
 	return out, err
 }
 
 // DeleteContactNote ...
 func (c *Client) DeleteContactNote(contact_id, note_id int) error {
+	return c.DeleteContactNoteContext(context.Background(), contact_id, note_id)
+}
+
+// DeleteContactNoteContext ...
+func (c *Client) DeleteContactNoteContext(ctx context.Context, contact_id, note_id int) error {
 	r := fmt.Sprintf("api/contacts/%v/notes/%v", contact_id, note_id)
-	return c.delete(r)
+	return c.delete(ctx, r)
 }
 
 // CreateDealNote ...
 func (c *Client) CreateDealNote(id int, in Note) (*Note, error) {
+	return c.CreateDealNoteContext(context.Background(), id, in)
+}
+
+// CreateDealNoteContext ...
+func (c *Client) CreateDealNoteContext(ctx context.Context, id int, in Note) (*Note, error) {
 	in.Count = nil
 	did := fmt.Sprintf("%v", id)
 	in.DealIDs = append(in.DealIDs, did)
 	in.ContactIDs = []string{}
 
-	_, err := c.send("PUT", "api/opportunity/deals/notes", nil, in, &in)
+	_, err := c.send(ctx, "PUT", "api/opportunity/deals/notes", nil, in, &in)
 	if err != nil {
 		return nil, err
 	}
@@ -91,9 +117,14 @@ func (c *Client) CreateDealNote(id int, in Note) (*Note, error) {
 
 // GetDealNotes ...
 func (c *Client) GetDealNotes(id int64) (NoteList, error) {
+	return c.GetDealNotesContext(context.Background(), id)
+}
+
+// GetDealNotesContext ...
+func (c *Client) GetDealNotesContext(ctx context.Context, id int64) (NoteList, error) {
 	r := fmt.Sprintf("api/opportunity/%v/notes", id)
 	out := NoteList{}
-	_, err := c.get("GET", r, nil, nil, &out)
+	_, err := c.get(ctx, "GET", r, nil, nil, &out)
 	return out, err
 }
 
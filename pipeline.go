@@ -0,0 +1,257 @@
+package agilecrm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidMilestone is returned when a deal's milestone isn't part of its
+// pipeline
+var ErrInvalidMilestone = fmt.Errorf("milestone is not in the pipeline")
+
+// Milestones is the ordered list of a pipeline's milestones, which the API
+// sends as a comma separated string
+type Milestones []string
+
+// MarshalJSON ...
+func (m Milestones) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(m, ","))
+}
+
+// UnmarshalJSON ...
+func (m *Milestones) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+
+	out := Milestones{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	*m = out
+	return nil
+}
+
+// Pipeline is a deal track, the sequence of milestones its deals move
+// through
+type Pipeline struct {
+	ID            ID         `json:"id,omitempty"`
+	Name          string     `json:"name,omitempty"`
+	Milestones    Milestones `json:"milestones"`
+	WonMilestone  string     `json:"won_milestone,omitempty"`
+	LostMilestone string     `json:"lost_milestone,omitempty"`
+	IsDefault     bool       `json:"isDefault,omitempty"`
+}
+
+type PipelineList []Pipeline
+
+// HasMilestone reports whether the milestone is part of the pipeline,
+// ignoring case
+func (p Pipeline) HasMilestone(name string) bool {
+	for _, m := range p.Milestones {
+		if strings.EqualFold(m, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns the default pipeline, or the first one when none is
+// marked as the default
+func (pl PipelineList) Default() (Pipeline, bool) {
+	for _, p := range pl {
+		if p.IsDefault {
+			return p, true
+		}
+	}
+	if len(pl) > 0 {
+		return pl[0], true
+	}
+	return Pipeline{}, false
+}
+
+// DealStatus tells whether the deal is open, won or lost, going by the won
+// and lost milestones of its pipeline, or of the default pipeline when the
+// deal has none. Pipelines without them use "Won" and "Lost".
+func (pl PipelineList) DealStatus(d Deal) DealStatus {
+	p, ok := Pipeline{}, false
+	for _, cur := range pl {
+		if cur.ID == d.PipelineID {
+			p, ok = cur, true
+			break
+		}
+	}
+	if !ok {
+		p, _ = pl.Default()
+	}
+
+	won, lost := p.WonMilestone, p.LostMilestone
+	if won == "" {
+		won = "Won"
+	}
+	if lost == "" {
+		lost = "Lost"
+	}
+
+	switch {
+	case strings.EqualFold(d.Milestone, won):
+		return DealsWon
+	case strings.EqualFold(d.Milestone, lost):
+		return DealsLost
+	}
+	return DealsOpen
+}
+
+// ListPipelines ...
+func (c *Client) ListPipelines() (PipelineList, error) {
+	return c.ListPipelinesContext(context.Background())
+}
+
+// ListPipelinesContext ...
+func (c *Client) ListPipelinesContext(ctx context.Context) (PipelineList, error) {
+	out := PipelineList{}
+	_, err := c.get(ctx, "GET", "api/milestone/pipelines", nil, nil, &out)
+	return out, err
+}
+
+// GetPipeline ...
+func (c *Client) GetPipeline(id int64) (*Pipeline, error) {
+	return c.GetPipelineContext(context.Background(), id)
+}
+
+// GetPipelineContext ...
+func (c *Client) GetPipelineContext(ctx context.Context, id int64) (*Pipeline, error) {
+	r := fmt.Sprintf("api/milestone/pipelines/%v", id)
+	out := &Pipeline{}
+	err := c.findByID(ctx, r, out)
+	return out, err
+}
+
+// CreatePipeline ...
+func (c *Client) CreatePipeline(in Pipeline) (*Pipeline, error) {
+	return c.CreatePipelineContext(context.Background(), in)
+}
+
+// CreatePipelineContext ...
+func (c *Client) CreatePipelineContext(ctx context.Context, in Pipeline) (*Pipeline, error) {
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+
+	in.ID = 0
+	_, err := c.send(ctx, "POST", "api/milestone/pipelines", nil, in, &in)
+	if err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// UpdatePipeline replaces the pipeline's name and milestones
+func (c *Client) UpdatePipeline(id int64, in Pipeline) (*Pipeline, error) {
+	return c.UpdatePipelineContext(context.Background(), id, in)
+}
+
+// UpdatePipelineContext ...
+func (c *Client) UpdatePipelineContext(ctx context.Context, id int64, in Pipeline) (*Pipeline, error) {
+	if err := in.validate(); err != nil {
+		return nil, err
+	}
+
+	in.ID = ID(id)
+	_, err := c.send(ctx, "PUT", "api/milestone/pipelines", nil, in, &in)
+	if err != nil {
+		return nil, err
+	}
+	return &in, nil
+}
+
+// DeletePipeline ...
+func (c *Client) DeletePipeline(id int64) error {
+	return c.DeletePipelineContext(context.Background(), id)
+}
+
+// DeletePipelineContext ...
+func (c *Client) DeletePipelineContext(ctx context.Context, id int64) error {
+	r := fmt.Sprintf("api/milestone/pipelines/%v", id)
+	return c.delete(ctx, r)
+}
+
+// validate checks the pipeline has milestones, and that its won and lost
+// milestones are among them
+func (p Pipeline) validate() error {
+	if len(p.Milestones) == 0 {
+		return fmt.Errorf("pipeline %q has no milestones", p.Name)
+	}
+	for _, m := range []string{p.WonMilestone, p.LostMilestone} {
+		if m != "" && !p.HasMilestone(m) {
+			return fmt.Errorf("pipeline %q: %q: %w", p.Name, m, ErrInvalidMilestone)
+		}
+	}
+	return nil
+}
+
+// ValidateDealMilestone checks the deal's milestone is part of its pipeline,
+// or of the default pipeline when the deal has none. A deal without a
+// milestone is valid.
+func (c *Client) ValidateDealMilestone(d Deal) error {
+	return c.ValidateDealMilestoneContext(context.Background(), d)
+}
+
+// ValidateDealMilestoneContext ...
+func (c *Client) ValidateDealMilestoneContext(ctx context.Context, d Deal) error {
+	if d.Milestone == "" {
+		return nil
+	}
+
+	var p Pipeline
+	if d.PipelineID != 0 {
+		found, err := c.GetPipelineContext(ctx, int64(d.PipelineID))
+		if err != nil {
+			return err
+		}
+		p = *found
+	} else {
+		pl, err := c.ListPipelinesContext(ctx)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if p, ok = pl.Default(); !ok {
+			return fmt.Errorf("account has no pipelines")
+		}
+	}
+
+	if !p.HasMilestone(d.Milestone) {
+		return fmt.Errorf("deal milestone %q, pipeline %q: %w", d.Milestone, p.Name, ErrInvalidMilestone)
+	}
+	return nil
+}
+
+// validateDealUpdate checks the milestone a partial update leaves the deal
+// in, filling in the current milestone or pipeline when only one of them
+// changes
+func (c *Client) validateDealUpdate(ctx context.Context, id int64, in Deal) error {
+	if in.Milestone == "" && in.PipelineID == 0 {
+		return nil
+	}
+
+	if in.Milestone == "" || in.PipelineID == 0 {
+		cur, err := c.FindDealByIDContext(ctx, int(id))
+		if err != nil {
+			return err
+		}
+		if in.Milestone == "" {
+			in.Milestone = cur.Milestone
+		}
+		if in.PipelineID == 0 {
+			in.PipelineID = cur.PipelineID
+		}
+	}
+
+	return c.ValidateDealMilestoneContext(ctx, in)
+}
@@ -1,16 +1,41 @@
 package agilecrm
 
+import "strings"
+
 const (
 	TypeSystem = "SYSTEM"
+	TypeCustom = "CUSTOM"
 
-	SubtypeWork = "work"
+	SubtypeWork     = "work"
+	SubtypeHome     = "home"
+	SubtypePersonal = "personal"
+	SubtypeMobile   = "mobile"
+	SubtypeMain     = "main"
+	SubtypeHomeFax  = "home fax"
+	SubtypeWorkFax  = "work fax"
+	SubtypeOther    = "other"
 )
 
-// Property ...
+// names of the system properties of contacts and companies
+const (
+	PropFirstName = "first_name"
+	PropLastName  = "last_name"
+	PropName      = "name"
+	PropCompany   = "company"
+	PropTitle     = "title"
+	PropEmail     = "email"
+	PropPhone     = "phone"
+	PropWebsite   = "website"
+	PropAddress   = "address"
+)
+
+// Property is one field of a contact or company. Its value is always sent,
+// even when empty, since that is how the API is told to clear a property;
+// properties that shouldn't change are left out of the list instead.
 type Property struct {
 	Name    string `json:"name,omitempty"`
 	Type    string `json:"type,omitempty"`
-	Value   string `json:"value,omitempty"`
+	Value   string `json:"value"`
 	Subtype string `json:"subtype,omitempty"`
 }
 
@@ -26,3 +51,102 @@ func (pl PropertyList) Find(i string) (string, string) {
 
 	return "", ""
 }
+
+// Get returns the first property with the name and subtype. An empty
+// subtype matches any subtype.
+func (pl PropertyList) Get(name, subtype string) (Property, bool) {
+	for _, p := range pl {
+		if !strings.EqualFold(p.Name, name) {
+			continue
+		}
+		if subtype == "" || strings.EqualFold(p.Subtype, subtype) {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Value returns the value of the first property with the name and subtype,
+// or an empty string
+func (pl PropertyList) Value(name, subtype string) string {
+	p, _ := pl.Get(name, subtype)
+	return p.Value
+}
+
+// exact returns the property with the name and exactly the subtype
+func (pl PropertyList) exact(name, subtype string) (Property, bool) {
+	for _, p := range pl {
+		if strings.EqualFold(p.Name, name) && strings.EqualFold(p.Subtype, subtype) {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// subtypeValue is Value with an exact subtype match, where an empty subtype
+// only matches properties without one
+func (pl PropertyList) subtypeValue(name, subtype string) string {
+	p, _ := pl.exact(name, subtype)
+	return p.Value
+}
+
+// All returns every property with the name, which is how multi-valued
+// properties like email and phone are stored
+func (pl PropertyList) All(name string) PropertyList {
+	out := PropertyList{}
+	for _, p := range pl {
+		if strings.EqualFold(p.Name, name) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Set replaces the value of the first property with the same name and
+// subtype, keeping its type, or adds the property when there is none. A new
+// property without a type is added as a system one.
+func (pl *PropertyList) Set(p Property) {
+	for i, cur := range *pl {
+		if strings.EqualFold(cur.Name, p.Name) && strings.EqualFold(cur.Subtype, p.Subtype) {
+			(*pl)[i].Value = p.Value
+			if p.Type != "" {
+				(*pl)[i].Type = p.Type
+			}
+			return
+		}
+	}
+
+	if p.Type == "" {
+		p.Type = TypeSystem
+	}
+	*pl = append(*pl, p)
+}
+
+// Add adds another value to a multi-valued property, unless the same name,
+// subtype and value is already there. A property without a type is added as
+// a system one.
+func (pl *PropertyList) Add(p Property) {
+	for _, cur := range *pl {
+		if strings.EqualFold(cur.Name, p.Name) && strings.EqualFold(cur.Subtype, p.Subtype) && cur.Value == p.Value {
+			return
+		}
+	}
+
+	if p.Type == "" {
+		p.Type = TypeSystem
+	}
+	*pl = append(*pl, p)
+}
+
+// Remove deletes the properties with the name and subtype. An empty subtype
+// removes them all.
+func (pl *PropertyList) Remove(name, subtype string) {
+	out := PropertyList{}
+	for _, p := range *pl {
+		if strings.EqualFold(p.Name, name) && (subtype == "" || strings.EqualFold(p.Subtype, subtype)) {
+			continue
+		}
+		out = append(out, p)
+	}
+	*pl = out
+}
@@ -0,0 +1,125 @@
+package agilecrm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitStats describes how much time requests have spent waiting on the
+// client's rate limiter.
+type RateLimitStats struct {
+	// Requests is the number of requests that went through the limiter
+	Requests int64
+
+	// Delayed is the number of those requests that had to wait for a token
+	Delayed int64
+
+	// Waiting is the number of requests currently blocked on the limiter
+	Waiting int64
+
+	// TotalWait and MaxWait are the cumulative and longest waits so far
+	TotalWait time.Duration
+	MaxWait   time.Duration
+
+	// LastWait is the wait of the most recently delayed request
+	LastWait time.Duration
+}
+
+// limiter is a token bucket shared by every goroutine using a Client
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	stats  RateLimitStats
+}
+
+// newLimiter returns nil when rate limiting is disabled
+func newLimiter(rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token, returning how long the caller has to wait before
+// it may be used
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	l.stats.Requests++
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	d := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.stats.Delayed++
+	l.stats.Waiting++
+	l.stats.TotalWait += d
+	l.stats.LastWait = d
+	if d > l.stats.MaxWait {
+		l.stats.MaxWait = d
+	}
+	return d
+}
+
+// wait blocks until a token is available or the context is done
+func (l *limiter) wait(ctx context.Context) error {
+	d := l.reserve()
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		l.mu.Lock()
+		l.stats.Waiting--
+		l.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		// hand the unused token back to the other waiters
+		l.mu.Lock()
+		l.tokens++
+		l.stats.Waiting--
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// snapshot ...
+func (l *limiter) snapshot() RateLimitStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stats
+}
+
+// RateLimitStats returns the wait statistics of the client's rate limiter.
+// It is all zeroes when no rate limit is configured.
+func (c *Client) RateLimitStats() RateLimitStats {
+	if c.limit == nil {
+		return RateLimitStats{}
+	}
+	return c.limit.snapshot()
+}
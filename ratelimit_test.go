@@ -0,0 +1,84 @@
+package agilecrm_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+func TestRateLimit(t *testing.T) {
+	const (
+		rate    = 20
+		workers = 6
+	)
+	srv, cl := newClient(t, func(cfg *agilecrm.Config) {
+		cfg.RateLimit = rate
+		cfg.RateBurst = 1
+	})
+	defer srv.Close()
+	ctc := newContact(t, cl, "limit@example.com")
+	before := cl.RateLimitStats()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cl.FindContactById(int(ctc.ID)); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// with a burst of one, the requests are spaced an interval apart
+	min := time.Duration(workers-1) * time.Second / rate
+	if elapsed < min*8/10 {
+		t.Errorf("%v requests took %v, want at least %v", workers, elapsed, min)
+	}
+
+	st := cl.RateLimitStats()
+	if got := st.Requests - before.Requests; got != workers {
+		t.Errorf("limiter saw %v requests, want %v", got, workers)
+	}
+	if st.Delayed-before.Delayed < workers-1 {
+		t.Errorf("delayed = %v, want at least %v", st.Delayed-before.Delayed, workers-1)
+	}
+	if st.Waiting != 0 {
+		t.Errorf("waiting = %v after every request returned", st.Waiting)
+	}
+}
+
+func TestRateLimitContextCancel(t *testing.T) {
+	srv, cl := newClient(t, func(cfg *agilecrm.Config) {
+		cfg.RateLimit = 0.1
+		cfg.RateBurst = 1
+	})
+	defer srv.Close()
+	newContact(t, cl, "cancel@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := cl.ListContactsContext(ctx, 0, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want the context's", err)
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	if _, err := cl.ListContacts(0, ""); err != nil && !errors.Is(err, agilecrm.ErrNoContacts) {
+		t.Fatal(err)
+	}
+	if st := cl.RateLimitStats(); st != (agilecrm.RateLimitStats{}) {
+		t.Errorf("stats = %+v without a rate limit", st)
+	}
+}
@@ -0,0 +1,229 @@
+package agilecrm
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMinBackoff = time.Millisecond * 500
+	defaultMaxBackoff = time.Second * 30
+)
+
+// RetryPolicy controls how failed requests are retried. The zero value
+// disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first one. Values below 2 disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the wait before the first retry, doubled on every
+	// following attempt up to MaxBackoff. Defaults to 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction (0 to 1) of each backoff that is randomized
+	Jitter float64
+
+	// StatusCodes are the response codes that trigger a retry. Defaults to
+	// 429, 502, 503 and 504.
+	StatusCodes []int
+
+	// Methods are the HTTP methods that may be retried. Defaults to the
+	// idempotent GET, HEAD, PUT and DELETE; POST is only retried when it is
+	// listed here explicitly, apart from the read-only POST lookups (company
+	// listing, filters and batch email lookups) which are always retried.
+	Methods []string
+
+	// IgnoreRetryAfter makes the policy always use its own backoff, even
+	// when the API sends a Retry-After header.
+	IgnoreRetryAfter bool
+}
+
+// DefaultRetryPolicy returns a policy suitable for most jobs: four attempts
+// with jittered exponential backoff for idempotent requests.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MinBackoff:  defaultMinBackoff,
+		MaxBackoff:  defaultMaxBackoff,
+		Jitter:      0.5,
+	}
+}
+
+// enabled ...
+func (p RetryPolicy) enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+// retryMethod ...
+func (p RetryPolicy) retryMethod(method string) bool {
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = []string{"GET", "HEAD", "PUT", "DELETE"}
+	}
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryStatus ...
+func (p RetryPolicy) retryStatus(code int) bool {
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the wait before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	min := p.MinBackoff
+	if min <= 0 {
+		min = defaultMinBackoff
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	d := float64(min) * math.Pow(2, float64(retry-1))
+	if d > float64(max) {
+		d = float64(max)
+	}
+
+	if p.Jitter > 0 {
+		j := p.Jitter
+		if j > 1 {
+			j = 1
+		}
+		d -= d * j * rand.Float64()
+	}
+
+	return time.Duration(d)
+}
+
+// retryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	h := strings.TrimSpace(res.Header.Get("Retry-After"))
+	if h == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(h); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(h); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+
+	return 0, false
+}
+
+// idempotentKey marks a request context as safe to retry whatever its method
+type idempotentKey struct{}
+
+// idempotent marks the requests made with the returned context as safe to
+// retry, for lookups the API only offers as POST
+func idempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent ...
+func isIdempotent(ctx context.Context) bool {
+	v, _ := ctx.Value(idempotentKey{}).(bool)
+	return v
+}
+
+// attempt sends the request once, waiting on the rate limiter first
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if c.limit != nil {
+		if err := c.limit.wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return c.ht.Do(req)
+}
+
+// do sends the request, retrying it according to the client's policy
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	p := c.retry
+	if !p.enabled() || !(p.retryMethod(req.Method) || isIdempotent(req.Context())) {
+		return c.attempt(req)
+	}
+
+	// a body that can't be rewound can only be sent once
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return c.attempt(req)
+	}
+
+	ctx := req.Context()
+	cur := req
+
+	for n := 1; ; n++ {
+		res, err := c.attempt(cur)
+
+		if n >= p.MaxAttempts || ctx.Err() != nil {
+			return res, err
+		}
+
+		if err == nil && !p.retryStatus(res.StatusCode) {
+			return res, nil
+		}
+
+		wait := p.backoff(n)
+		if err == nil {
+			if ra, ok := retryAfter(res); ok && !p.IgnoreRetryAfter {
+				wait = ra
+			}
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+
+		cur = req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			cur.Body = body
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package agilecrm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+func TestRetry(t *testing.T) {
+	// backoffs long enough to time the test out show a Retry-After of zero
+	// wasn't honored
+	fast := agilecrm.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	slow := agilecrm.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	tests := []struct {
+		name     string
+		policy   agilecrm.RetryPolicy
+		fault    agilecrmtest.Fault
+		wantErr  int
+		requests int
+	}{
+		{
+			name:     "503 then success",
+			policy:   fast,
+			fault:    agilecrmtest.Fault{Route: "api/contacts", Status: http.StatusServiceUnavailable, Times: 2},
+			requests: 3,
+		},
+		{
+			name:     "429 honors Retry-After",
+			policy:   slow,
+			fault:    agilecrmtest.Fault{Route: "api/contacts", Status: http.StatusTooManyRequests, RetryAfter: "0", Times: 1},
+			requests: 2,
+		},
+		{
+			name:     "503 until attempts run out",
+			policy:   fast,
+			fault:    agilecrmtest.Fault{Route: "api/contacts", Status: http.StatusServiceUnavailable},
+			wantErr:  http.StatusServiceUnavailable,
+			requests: 3,
+		},
+		{
+			name:     "400 is not retried",
+			policy:   fast,
+			fault:    agilecrmtest.Fault{Route: "api/contacts", Status: http.StatusBadRequest},
+			wantErr:  http.StatusBadRequest,
+			requests: 1,
+		},
+		{
+			name:     "zero policy makes one attempt",
+			fault:    agilecrmtest.Fault{Route: "api/contacts", Status: http.StatusServiceUnavailable, Times: 1},
+			wantErr:  http.StatusServiceUnavailable,
+			requests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.Retry = tt.policy })
+			defer srv.Close()
+			ctc := newContact(t, cl, "retry@example.com")
+
+			srv.Inject(tt.fault)
+			before := srv.Requests()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, err := cl.FindContactByIdContext(ctx, int(ctc.ID))
+
+			if got := srv.Requests() - before; got != tt.requests {
+				t.Errorf("requests = %v, want %v", got, tt.requests)
+			}
+
+			if tt.wantErr == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error; %v", err)
+				}
+				return
+			}
+
+			var apiErr *agilecrm.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantErr {
+				t.Fatalf("error = %v, want status %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPostNeedsOptIn(t *testing.T) {
+	policy := agilecrm.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		methods  []string
+		requests int
+	}{
+		{name: "default methods", requests: 1},
+		{name: "POST listed", methods: []string{"POST"}, requests: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := policy
+			p.Methods = tt.methods
+			srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.Retry = p })
+			defer srv.Close()
+
+			srv.Inject(agilecrmtest.Fault{Method: "POST", Route: "api/contacts", Status: http.StatusServiceUnavailable, Times: 1})
+			before := srv.Requests()
+
+			in := agilecrm.Contact{}
+			in.SetEmail("", "post@example.com")
+			cl.CreateContact(in)
+
+			if got := srv.Requests() - before; got != tt.requests {
+				t.Errorf("requests = %v, want %v", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestRetryReadOnlyPost(t *testing.T) {
+	// the default methods leave POST out, but these lookups only read
+	policy := agilecrm.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name  string
+		route string
+		call  func(cl *agilecrm.Client) error
+	}{
+		{"list companies", "api/contacts/companies/list", func(cl *agilecrm.Client) error {
+			_, err := cl.ListCompanies()
+			return err
+		}},
+		{"list companies page", "api/contacts/companies/list", func(cl *agilecrm.Client) error {
+			_, err := cl.ListCompaniesPage(10, "")
+			return err
+		}},
+		{"contact filter", "api/filters/filter/dynamic-filter", func(cl *agilecrm.Client) error {
+			_, err := cl.FilterContacts(agilecrm.Filter().Where("tags", agilecrm.FilterEqual, "vip"))
+			return err
+		}},
+		{"deal filter", "api/opportunity/based", func(cl *agilecrm.Client) error {
+			_, err := cl.FilterDeals(agilecrm.Filter().Where("milestone", agilecrm.FilterEqual, "New"))
+			return err
+		}},
+		{"batch email lookup", "api/contacts/search/email", func(cl *agilecrm.Client) error {
+			_, err := cl.FindContactsByEmail([]string{"a@example.com", "b@example.com"})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, func(cfg *agilecrm.Config) { cfg.Retry = policy })
+			defer srv.Close()
+			srv.AddContact(agilecrm.Contact{Type: agilecrm.TypeCompany})
+
+			srv.Inject(agilecrmtest.Fault{Method: "POST", Route: tt.route, Status: http.StatusServiceUnavailable, Times: 1})
+			before := srv.Requests()
+
+			if err := tt.call(cl); err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+			if got := srv.Requests() - before; got != 2 {
+				t.Errorf("requests = %v, want 2", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package agilecrm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// star values range from no stars to five
+const (
+	minStarValue = 0
+	maxStarValue = 5
+)
+
+// AddContactScore adds to the lead score of the contact with the email. The
+// change is made by the API, so concurrent changes don't overwrite each
+// other. A negative delta subtracts from the score.
+func (c *Client) AddContactScore(email string, delta int) (*Contact, error) {
+	return c.AddContactScoreContext(context.Background(), email, delta)
+}
+
+// AddContactScoreContext ...
+func (c *Client) AddContactScoreContext(ctx context.Context, email string, delta int) (*Contact, error) {
+	if delta < 0 {
+		return c.changeScore(ctx, "api/contacts/subtract-score", email, -delta)
+	}
+	return c.changeScore(ctx, "api/contacts/add-score", email, delta)
+}
+
+// SubtractContactScore subtracts from the lead score of the contact with the
+// email
+func (c *Client) SubtractContactScore(email string, delta int) (*Contact, error) {
+	return c.SubtractContactScoreContext(context.Background(), email, delta)
+}
+
+// SubtractContactScoreContext ...
+func (c *Client) SubtractContactScoreContext(ctx context.Context, email string, delta int) (*Contact, error) {
+	return c.AddContactScoreContext(ctx, email, -delta)
+}
+
+// AddContactScoreByID is AddContactScore for a contact given by ID. The
+// score is changed through the contact's email, which it needs to have.
+func (c *Client) AddContactScoreByID(id int64, delta int) (*Contact, error) {
+	return c.AddContactScoreByIDContext(context.Background(), id, delta)
+}
+
+// AddContactScoreByIDContext ...
+func (c *Client) AddContactScoreByIDContext(ctx context.Context, id int64, delta int) (*Contact, error) {
+	ctc, err := c.FindContactByIdContext(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+
+	email := ctc.Email()
+	if email == "" {
+		return nil, fmt.Errorf("contact %v has no email to change its score by", id)
+	}
+	return c.AddContactScoreContext(ctx, email, delta)
+}
+
+// SubtractContactScoreByID is SubtractContactScore for a contact given by ID
+func (c *Client) SubtractContactScoreByID(id int64, delta int) (*Contact, error) {
+	return c.SubtractContactScoreByIDContext(context.Background(), id, delta)
+}
+
+// SubtractContactScoreByIDContext ...
+func (c *Client) SubtractContactScoreByIDContext(ctx context.Context, id int64, delta int) (*Contact, error) {
+	return c.AddContactScoreByIDContext(ctx, id, -delta)
+}
+
+// changeScore ...
+func (c *Client) changeScore(ctx context.Context, route, email string, delta int) (*Contact, error) {
+	vals := url.Values{}
+	vals.Add("email", email)
+	vals.Add("score", fmt.Sprintf("%v", delta))
+
+	req, err := c.postForm(ctx, "POST", route, strings.NewReader(vals.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Contact{}
+	st, err := c.processResults(req, out)
+	if err != nil {
+		return nil, err
+	}
+
+	if st == http.StatusNoContent {
+		return nil, statusError("POST", route, st, ErrNoSuchContact)
+	}
+
+	// some accounts answer without the contact, which is then looked up
+	if out.ID == 0 {
+		return c.FindContactByEmailContext(ctx, email)
+	}
+	return out, nil
+}
+
+// AdjustContactStarValue adds delta stars to the contact, keeping the result
+// between 0 and 5. Unlike scores, stars can only be set, so the change is
+// made by reading the current value first.
+func (c *Client) AdjustContactStarValue(id int64, delta int) (*Contact, error) {
+	return c.AdjustContactStarValueContext(context.Background(), id, delta)
+}
+
+// AdjustContactStarValueContext ...
+func (c *Client) AdjustContactStarValueContext(ctx context.Context, id int64, delta int) (*Contact, error) {
+	ctc, err := c.FindContactByIdContext(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
+
+	star := int64(ctc.StarValue) + int64(delta)
+	if star < minStarValue {
+		star = minStarValue
+	}
+	if star > maxStarValue {
+		star = maxStarValue
+	}
+	return c.UpdateContactStarValueContext(ctx, id, star)
+}
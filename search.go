@@ -1,22 +1,50 @@
 package agilecrm
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/url"
 	"strings"
 )
 
-type filterCondition string
+// FilterCondition is the comparison a dynamic filter rule makes between a
+// field and its value(s)
+type FilterCondition string
 
 const (
-	FilterEqual filterCondition = "EQUALS"
-	FilterOn    filterCondition = "ON"
+	FilterEqual       FilterCondition = "EQUALS"
+	FilterNotEqual    FilterCondition = "NOTEQUALS"
+	FilterContains    FilterCondition = "CONTAINS"
+	FilterNotContains FilterCondition = "NOT_CONTAINS"
+	FilterGreaterThan FilterCondition = "IS_GREATER_THAN"
+	FilterLessThan    FilterCondition = "IS_LESS_THAN"
+	FilterBetween     FilterCondition = "BETWEEN"
+	FilterOn          FilterCondition = "ON"
+	FilterBefore      FilterCondition = "BEFORE"
+	FilterAfter       FilterCondition = "AFTER"
+	FilterLast        FilterCondition = "LAST"
+	FilterNext        FilterCondition = "NEXT"
+	FilterDefined     FilterCondition = "DEFINED"
+	FilterNotDefined  FilterCondition = "NOT_DEFINED"
 )
 
+// operands returns how many values the condition compares against
+func (fc FilterCondition) operands() int {
+	switch fc {
+	case FilterBetween:
+		return 2
+	case FilterDefined, FilterNotDefined:
+		return 0
+	}
+	return 1
+}
+
 type filterRule struct {
 	Left      string          `json:"LHS"`
-	Condition filterCondition `json:"CONDITION"`
+	Condition FilterCondition `json:"CONDITION"`
 	Right     string          `json:"RHS"`
+	RightNew  string          `json:"RHS_NEW,omitempty"`
 }
 
 type filterJson struct {
@@ -26,13 +54,13 @@ type filterJson struct {
 }
 
 // addRule ...
-func (fj *filterJson) addRule(l, r string, c filterCondition) {
-	fj.Rules = append(fj.Rules, filterRule{l, c, r})
+func (fj *filterJson) addRule(r filterRule) {
+	fj.Rules = append(fj.Rules, r)
 }
 
 // addOrRule ...
-func (fj *filterJson) addOrRule(l, r string, c filterCondition) {
-	fj.OrRules = append(fj.OrRules, filterRule{l, c, r})
+func (fj *filterJson) addOrRule(r filterRule) {
+	fj.OrRules = append(fj.OrRules, r)
 }
 
 // setType ...
@@ -41,19 +69,33 @@ func (fj *filterJson) setType(ct ContactType) {
 }
 
 // dynamicFilter ...
-func (c *Client) dynamicFilter(f filterJson, out interface{}) error {
-	bits, err := json.Marshal(f)
+func (c *Client) dynamicFilter(ctx context.Context, q *FilterQuery, out interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	bits, err := json.Marshal(q.filter)
 	if err != nil {
 		return err
 	}
 
 	v := url.Values{}
-	v.Add("page_size", "10")
-	v.Add("global_sort_key", "-created_time")
+	v.Add("page_size", fmt.Sprintf("%v", q.pageSize()))
+	if q.cursor != "" {
+		v.Add("cursor", q.cursor)
+	}
+	v.Add("global_sort_key", q.sortKey())
 	v.Add("filterJson", string(bits))
-	q := v.Encode()
+	body := v.Encode()
+
+	// deals have their own filter endpoint, taking the same filter
+	route := "api/filters/filter/dynamic-filter"
+	if q.filter.ContactType == TypeDeal {
+		route = "api/opportunity/based"
+	}
 
-	req, err := c.postForm("POST", "api/filters/filter/dynamic-filter", strings.NewReader(q), nil)
+	// filters only read, so they are retried like a GET
+	req, err := c.postForm(idempotent(ctx), "POST", route, strings.NewReader(body), nil)
 	if err != nil {
 		return err
 	}
@@ -62,35 +104,79 @@ func (c *Client) dynamicFilter(f filterJson, out interface{}) error {
 	return err
 }
 
-// FindContactsByTag ...
-func (c *Client) FindContactsByTag(tag string) (ContactList, error) {
-	fj := filterJson{}
-	fj.addRule("tags", tag, FilterEqual)
-	fj.setType(TypeContact)
+// defaultSearchPageSize is used by searches made without a page size
+const defaultSearchPageSize = 10
+
+// SearchOptions selects what a keyword search returns. Type defaults to
+// TypeContact.
+type SearchOptions struct {
+	Type    ContactType
+	PerPage int
+	Cursor  string
+}
+
+// search runs a keyword search, which matches names, emails, phone numbers,
+// companies and the like
+func (c *Client) search(ctx context.Context, query string, opts SearchOptions, out interface{}) error {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return fmt.Errorf("search query is required")
+	}
+
+	if opts.PerPage <= 0 {
+		opts.PerPage = defaultSearchPageSize
+	}
+
+	params := map[string]string{
+		"q":         query,
+		"page_size": fmt.Sprintf("%v", opts.PerPage),
+		"type":      fmt.Sprintf("'%v'", opts.Type),
+	}
+	if opts.Cursor != "" {
+		params["cursor"] = opts.Cursor
+	}
+
+	// no results comes back as a 204, which leaves out empty
+	_, err := c.get(ctx, "GET", "api/search", nil, params, out)
+	return err
+}
+
+// findByTag returns a page of the contacts or companies with the tag
+func (c *Client) findByTag(ctx context.Context, typ ContactType, tag string, perPage int, cursor string) (ContactList, error) {
+	q := Filter().Where("tags", FilterEqual, tag).Type(typ).PageSize(perPage).Cursor(cursor)
 
 	out := ContactList{}
-	err := c.dynamicFilter(fj, &out)
+	err := c.dynamicFilter(ctx, q, &out)
 	return out, err
 }
 
+// FindContactsByTag ...
+func (c *Client) FindContactsByTag(tag string) (ContactList, error) {
+	return c.FindContactsByTagContext(context.Background(), tag)
+}
+
+// FindContactsByTagContext ...
+func (c *Client) FindContactsByTagContext(ctx context.Context, tag string) (ContactList, error) {
+	return c.findByTag(ctx, TypeContact, tag, 0, "")
+}
+
 // FindCompaniesByTag ...
 func (c *Client) FindCompaniesByTag(tag string) (ContactList, error) {
-	fj := filterJson{}
-	fj.addRule("tags", tag, FilterEqual)
-	fj.setType(TypeCompany)
+	return c.FindCompaniesByTagContext(context.Background(), tag)
+}
 
-	out := ContactList{}
-	err := c.dynamicFilter(fj, &out)
-	return out, err
+// FindCompaniesByTagContext ...
+func (c *Client) FindCompaniesByTagContext(ctx context.Context, tag string) (ContactList, error) {
+	return c.findByTag(ctx, TypeCompany, tag, 0, "")
 }
 
 // FindDealsByTag ...
 func (c *Client) FindDealsByTag(tag string) (DealList, error) {
-	fj := filterJson{}
-	fj.addRule("tags", tag, FilterEqual)
-	fj.setType(TypeDeal)
+	return c.FindDealsByTagContext(context.Background(), tag)
+}
 
-	out := DealList{}
-	err := c.dynamicFilter(fj, &out)
-	return out, err
+// FindDealsByTagContext ...
+func (c *Client) FindDealsByTagContext(ctx context.Context, tag string) (DealList, error) {
+	q := Filter().Where("tags", FilterEqual, tag).Type(TypeDeal)
+	return c.FilterDealsContext(ctx, q)
 }
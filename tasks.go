@@ -1,8 +1,8 @@
 package agilecrm
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 )
 
 type TaskType string
@@ -35,14 +35,14 @@ const (
 )
 
 type Task struct {
-	ID           int    `json:"id,omitempty"`
+	ID           ID     `json:"id,omitempty"`
 	Type         string `json:"type,omitempty"`
 	PriorityType string `json:"priority_type,omitempty"`
-	Due          int    `json:"due,omitempty"`
-	CreatedTime  int    `json:"created_time,omitempty"`
+	Due          Time   `json:"due,omitempty"`
+	CreatedTime  Time   `json:"created_time,omitempty"`
 	IsComplete   bool   `json:"is_complete,omitempty"`
 	Subject      string `json:"subject,omitempty"`
-	Progress     int    `json:"progress,omitempty"`
+	Progress     Int    `json:"progress,omitempty"`
 	Status       string `json:"status,omitempty"`
 	Owner        string `json:"owner,omitempty"`
 
@@ -59,7 +59,7 @@ type TaskCreate struct {
 	IsComplete     bool         `json:"is_complete"`
 	Subject        string       `json:"subject"`
 	Type           TaskType     `json:"type"`
-	Due            int64        `json:"due"`
+	Due            Time         `json:"due"`
 	TaskEndingTime string       `json:"task_ending_time"`
 	OwnerID        int64        `json:"owner_id"`
 	PriorityType   TaskPriority `json:"priority_type"`
@@ -74,7 +74,7 @@ type TaskCreate struct {
 type TaskList []Task
 
 type TaskOwner struct {
-	ID             int    `json:"id,omitempty"`
+	ID             ID     `json:"id,omitempty"`
 	Name           string `json:"name,omitempty"`
 	Email          string `json:"email,omitempty"`
 	Domain         string `json:"domain,omitempty"`
@@ -85,30 +85,45 @@ type TaskOwner struct {
 
 // ListTasks ...
 func (c *Client) ListTasks() (TaskList, error) {
+	return c.ListTasksContext(context.Background())
+}
+
+// ListTasksContext ...
+func (c *Client) ListTasksContext(ctx context.Context) (TaskList, error) {
 	out := TaskList{}
-	_, err := c.get("GET", "api/tasks", nil, nil, &out)
+	_, err := c.get(ctx, "GET", "api/tasks", nil, nil, &out)
 	return out, err
 }
 
 // GetContactTasks ...
 func (c *Client) GetContactTasks(id int) (TaskList, error) {
+	return c.GetContactTasksContext(context.Background(), id)
+}
+
+// GetContactTasksContext ...
+func (c *Client) GetContactTasksContext(ctx context.Context, id int) (TaskList, error) {
 	route := fmt.Sprintf("api/contacts/%v/tasks", id)
 	out := TaskList{}
-	st, err := c.get("GET", route, nil, nil, &out)
-	if st == http.StatusUnauthorized {
-		return TaskList{}, ErrUnauthorized
+	_, err := c.get(ctx, "GET", route, nil, nil, &out)
+	if err != nil {
+		return TaskList{}, err
 	}
-	return out, err
+	return out, nil
 }
 
 // GetPendingTasks ...
 func (c *Client) GetPendingTasks(numDays int) (TaskList, error) {
+	return c.GetPendingTasksContext(context.Background(), numDays)
+}
+
+// GetPendingTasksContext ...
+func (c *Client) GetPendingTasksContext(ctx context.Context, numDays int) (TaskList, error) {
 	if numDays < 1 {
 		numDays = 1
 	}
 	r := fmt.Sprintf("api/tasks/pending/%v", numDays)
 	out := TaskList{}
-	_, err := c.get("GET", r, nil, nil, &out)
+	_, err := c.get(ctx, "GET", r, nil, nil, &out)
 	return out, err
 }
 
@@ -116,30 +131,50 @@ func (c *Client) GetPendingTasks(numDays int) (TaskList, error) {
 
 // GetTaskByID ...
 func (c *Client) GetTaskByID(id int64) (*Task, error) {
+	return c.GetTaskByIDContext(context.Background(), id)
+}
+
+// GetTaskByIDContext ...
+func (c *Client) GetTaskByIDContext(ctx context.Context, id int64) (*Task, error) {
 	r := fmt.Sprintf("api/tasks/%v", id)
 	out := &Task{}
-	err := c.findByID(r, out)
+	err := c.findByID(ctx, r, out)
 	return out, err
 }
 
 // CreateTask ...
 func (c *Client) CreateTask(in TaskCreate) (*Task, error) {
+	return c.CreateTaskContext(context.Background(), in)
+}
+
+// CreateTaskContext ...
+func (c *Client) CreateTaskContext(ctx context.Context, in TaskCreate) (*Task, error) {
 	in.ID = nil
 	out := &Task{}
-	_, err := c.send("POST", "api/tasks", nil, in, out)
+	_, err := c.send(ctx, "POST", "api/tasks", nil, in, out)
 	return out, err
 }
 
 // UpdateTask ...
 func (c *Client) UpdateTask(id int64, in TaskCreate) (*Task, error) {
+	return c.UpdateTaskContext(context.Background(), id, in)
+}
+
+// UpdateTaskContext ...
+func (c *Client) UpdateTaskContext(ctx context.Context, id int64, in TaskCreate) (*Task, error) {
 	in.ID = &id
 	out := &Task{}
-	_, err := c.send("PUT", "api/tasks/partial-update", nil, in, out)
+	_, err := c.send(ctx, "PUT", "api/tasks/partial-update", nil, in, out)
 	return out, err
 }
 
 // DeleteTask ...
 func (c *Client) DeleteTask(id int64) error {
+	return c.DeleteTaskContext(context.Background(), id)
+}
+
+// DeleteTaskContext ...
+func (c *Client) DeleteTaskContext(ctx context.Context, id int64) error {
 	r := fmt.Sprintf("api/tasks/%v", id)
-	return c.delete(r)
+	return c.delete(ctx, r)
 }
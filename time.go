@@ -0,0 +1,168 @@
+package agilecrm
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// millisThreshold separates epoch millis from epoch seconds when decoding,
+// 1e11 seconds being far beyond any date the API deals with
+const millisThreshold = 1e11
+
+// Time is a timestamp as the API sends it, in epoch seconds. It decodes
+// from seconds or millis, given as numbers or strings, and from null, and
+// encodes as seconds. The zero Time is no time at all.
+type Time int64
+
+// NewTime converts a time.Time, the zero time.Time becoming the zero Time
+func NewTime(t time.Time) Time {
+	if t.IsZero() {
+		return 0
+	}
+	return Time(t.Unix())
+}
+
+// Time converts to a time.Time, the zero Time becoming the zero time.Time
+func (t Time) Time() time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(t), 0)
+}
+
+// IsZero ...
+func (t Time) IsZero() bool {
+	return t == 0
+}
+
+// Unix returns the time in epoch seconds
+func (t Time) Unix() int64 {
+	return int64(t)
+}
+
+// Millis returns the time in epoch millis, which filters and some fields use
+func (t Time) Millis() int64 {
+	return int64(t) * 1000
+}
+
+// String ...
+func (t Time) String() string {
+	if t == 0 {
+		return "0"
+	}
+	return t.Time().UTC().Format(time.RFC3339)
+}
+
+// MarshalJSON ...
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(t), 10)), nil
+}
+
+// UnmarshalJSON ...
+func (t *Time) UnmarshalJSON(b []byte) error {
+	n, isMillis, err := parseEpoch(b, reflect.TypeOf(*t))
+	if err != nil {
+		return err
+	}
+
+	if isMillis {
+		n /= 1000
+	}
+	*t = Time(n)
+	return nil
+}
+
+// MilliTime is a timestamp the API sends in epoch millis, like the times
+// of tags_with_time and viewed. It decodes the same values Time does but
+// encodes as millis, so fetched records are sent back unchanged.
+type MilliTime int64
+
+// NewMilliTime converts a time.Time, the zero time.Time becoming the zero
+// MilliTime
+func NewMilliTime(t time.Time) MilliTime {
+	if t.IsZero() {
+		return 0
+	}
+	return MilliTime(t.UnixNano() / int64(time.Millisecond))
+}
+
+// Time converts to a time.Time, the zero MilliTime becoming the zero
+// time.Time
+func (t MilliTime) Time() time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(t)*int64(time.Millisecond))
+}
+
+// IsZero ...
+func (t MilliTime) IsZero() bool {
+	return t == 0
+}
+
+// Unix returns the time in epoch seconds
+func (t MilliTime) Unix() int64 {
+	return int64(t) / 1000
+}
+
+// Millis returns the time in epoch millis
+func (t MilliTime) Millis() int64 {
+	return int64(t)
+}
+
+// String ...
+func (t MilliTime) String() string {
+	if t == 0 {
+		return "0"
+	}
+	return t.Time().UTC().Format(time.RFC3339Nano)
+}
+
+// MarshalJSON ...
+func (t MilliTime) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(t), 10)), nil
+}
+
+// UnmarshalJSON ...
+func (t *MilliTime) UnmarshalJSON(b []byte) error {
+	n, isMillis, err := parseEpoch(b, reflect.TypeOf(*t))
+	if err != nil {
+		return err
+	}
+
+	if !isMillis {
+		n *= 1000
+	}
+	*t = MilliTime(n)
+	return nil
+}
+
+// parseEpoch reads an epoch time given as a number, a string or null, and
+// tells whether it is in millis rather than seconds. Other values are
+// reported as not fitting the type t.
+func parseEpoch(b []byte, t reflect.Type) (float64, bool, error) {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return 0, false, nil
+	}
+
+	if len(b) > 0 && b[0] == '"' {
+		s := ""
+		if err := json.Unmarshal(b, &s); err != nil {
+			return 0, false, err
+		}
+		b = bytes.TrimSpace([]byte(s))
+		if len(b) == 0 {
+			return 0, false, nil
+		}
+	}
+
+	n, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return 0, false, typeError(b, t)
+	}
+	return n, n > millisThreshold || n < -millisThreshold, nil
+}
@@ -0,0 +1,146 @@
+package agilecrm_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Z2hMedia/agilecrm"
+)
+
+func TestTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    agilecrm.Time
+		wantMs  agilecrm.MilliTime
+		wantErr bool
+	}{
+		{name: "seconds", in: `1469166720`, want: 1469166720, wantMs: 1469166720000},
+		{name: "millis", in: `1469166720183`, want: 1469166720, wantMs: 1469166720183},
+		{name: "string seconds", in: `"1469166720"`, want: 1469166720, wantMs: 1469166720000},
+		{name: "string millis", in: `" 1469166720183 "`, want: 1469166720, wantMs: 1469166720183},
+		{name: "float seconds", in: `1469166720.0`, want: 1469166720, wantMs: 1469166720000},
+		{name: "at the threshold", in: `100000000000`, want: 100000000000, wantMs: 100000000000000},
+		{name: "past the threshold", in: `100000000001`, want: 100000000, wantMs: 100000000001},
+		{name: "negative millis", in: `-1469166720183`, want: -1469166720, wantMs: -1469166720183},
+		{name: "zero", in: `0`},
+		{name: "null", in: `null`},
+		{name: "empty string", in: `""`},
+		{name: "blank string", in: `"  "`},
+		{name: "word", in: `"soon"`, wantErr: true},
+		{name: "bool", in: `true`, wantErr: true},
+		{name: "object", in: `{}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got agilecrm.Time
+			err := json.Unmarshal([]byte(tt.in), &got)
+			var gotMs agilecrm.MilliTime
+			errMs := json.Unmarshal([]byte(tt.in), &gotMs)
+
+			if tt.wantErr {
+				var typeErr *json.UnmarshalTypeError
+				if !errors.As(err, &typeErr) || !errors.As(errMs, &typeErr) {
+					t.Fatalf("errors = %v and %v, want type errors", err, errMs)
+				}
+				return
+			}
+			if err != nil || errMs != nil {
+				t.Fatalf("unexpected errors; %v, %v", err, errMs)
+			}
+			if got != tt.want {
+				t.Errorf("Time = %v, want %v", int64(got), int64(tt.want))
+			}
+			if gotMs != tt.wantMs {
+				t.Errorf("MilliTime = %v, want %v", int64(gotMs), int64(tt.wantMs))
+			}
+		})
+	}
+}
+
+func TestTimeMarshalJSON(t *testing.T) {
+	at := time.Date(2016, 7, 22, 5, 52, 0, 183*int(time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{name: "Time", v: agilecrm.NewTime(at), want: `1469166720`},
+		{name: "zero Time", v: agilecrm.NewTime(time.Time{}), want: `0`},
+		{name: "MilliTime", v: agilecrm.NewMilliTime(at), want: `1469166720183`},
+		{name: "zero MilliTime", v: agilecrm.NewMilliTime(time.Time{}), want: `0`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bits, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(bits) != tt.want {
+				t.Errorf("encoded as %s, want %v", bits, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeRoundTrip(t *testing.T) {
+	// fetched records are sent back with their times in the same unit
+	type record struct {
+		Created agilecrm.Time      `json:"created"`
+		Viewed  agilecrm.MilliTime `json:"viewed"`
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"created":1469166720,"viewed":1469166720183}`, `{"created":1469166720,"viewed":1469166720183}`},
+		{`{"created":"1469166720","viewed":"1469166720183"}`, `{"created":1469166720,"viewed":1469166720183}`},
+		{`{"created":null,"viewed":null}`, `{"created":0,"viewed":0}`},
+	}
+
+	for _, tt := range tests {
+		r := record{}
+		if err := json.Unmarshal([]byte(tt.in), &r); err != nil {
+			t.Fatalf("unable to decode %s; %v", tt.in, err)
+		}
+		bits, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(bits) != tt.want {
+			t.Errorf("%s encoded back as %s, want %s", tt.in, bits, tt.want)
+		}
+	}
+}
+
+func TestTimeConversions(t *testing.T) {
+	at := time.Date(2016, 7, 22, 5, 52, 0, 183*int(time.Millisecond), time.UTC)
+
+	tm := agilecrm.NewTime(at)
+	if !tm.Time().Equal(at.Truncate(time.Second)) || tm.Unix() != 1469166720 || tm.Millis() != 1469166720000 {
+		t.Errorf("Time %v converts to %v, %v, %v", int64(tm), tm.Time(), tm.Unix(), tm.Millis())
+	}
+	if s := tm.String(); s != "2016-07-22T05:52:00Z" {
+		t.Errorf("Time string = %v", s)
+	}
+
+	ms := agilecrm.NewMilliTime(at)
+	if !ms.Time().Equal(at) || ms.Unix() != 1469166720 || ms.Millis() != 1469166720183 {
+		t.Errorf("MilliTime %v converts to %v, %v, %v", int64(ms), ms.Time(), ms.Unix(), ms.Millis())
+	}
+	if s := ms.String(); s != "2016-07-22T05:52:00.183Z" {
+		t.Errorf("MilliTime string = %v", s)
+	}
+
+	var zero agilecrm.Time
+	var zeroMs agilecrm.MilliTime
+	if !zero.IsZero() || !zero.Time().IsZero() || !zeroMs.IsZero() || !zeroMs.Time().IsZero() {
+		t.Error("zero times don't convert to the zero time.Time")
+	}
+}
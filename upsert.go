@@ -0,0 +1,209 @@
+package agilecrm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// MergeStrategy decides how the properties of an upsert are merged into an
+// existing contact
+type MergeStrategy int
+
+const (
+	// MergeOverwrite replaces the existing values of the properties given
+	MergeOverwrite MergeStrategy = iota
+
+	// MergeKeepExisting only adds properties the contact doesn't have a
+	// value for yet
+	MergeKeepExisting
+
+	// MergeAppendMulti adds emails, phones and websites as additional values
+	// and overwrites the other properties
+	MergeAppendMulti
+
+	// mergeUnion adds emails, phones and websites as additional values and
+	// keeps the existing values of the other properties, which is how
+	// contacts are merged
+	mergeUnion
+)
+
+// multiValued reports whether a contact can hold several values of the
+// property
+func multiValued(name string) bool {
+	switch strings.ToLower(name) {
+	case PropEmail, PropPhone, PropWebsite:
+		return true
+	}
+	return false
+}
+
+// merge merges the incoming properties into the existing ones, returning
+// the properties that changed
+func (ms MergeStrategy) merge(existing *PropertyList, incoming PropertyList) PropertyList {
+	changed := PropertyList{}
+	for _, p := range incoming {
+		if p.Value == "" {
+			continue
+		}
+
+		cur, ok := existing.exact(p.Name, p.Subtype)
+		switch {
+		case multiValued(p.Name) && existing.has(p.Name, p.Value):
+			continue
+		case (ms == MergeAppendMulti || ms == mergeUnion) && multiValued(p.Name):
+			existing.Add(p)
+			changed = append(changed, p)
+			continue
+		case (ms == MergeKeepExisting || ms == mergeUnion) && ok && cur.Value != "":
+			continue
+		case ok && cur.Value == p.Value:
+			continue
+		}
+
+		existing.Set(p)
+		changed = append(changed, p)
+	}
+	return changed
+}
+
+// has reports whether any property with the name has the value, whatever
+// its subtype, comparing emails and phones in their normalized forms
+func (pl PropertyList) has(name, value string) bool {
+	v := normalizeValue(name, value)
+	for _, p := range pl {
+		if strings.EqualFold(p.Name, name) && normalizeValue(name, p.Value) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeValue ...
+func normalizeValue(name, value string) string {
+	switch strings.ToLower(name) {
+	case PropEmail:
+		return normalizeEmail(value)
+	case PropPhone:
+		if n := normalizePhone(value); n != "" {
+			return n
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// keyedMutex serializes work on the same key, like an email address, using
+// a fixed set of locks so nothing has to be cleaned up
+type keyedMutex struct {
+	stripes [64]sync.Mutex
+}
+
+// lock locks the key and returns the function unlocking it
+func (km *keyedMutex) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &km.stripes[h.Sum32()%uint32(len(km.stripes))]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// UpsertContactByEmail creates the contact, or merges its properties and
+// tags into the existing contact with the same email, returning the contact
+// and whether it was created.
+//
+// Upserts of the same email through the client are run one at a time. A
+// contact created elsewhere between the lookup and the create is caught by
+// the API rejecting the duplicate, after which the contact is merged into
+// it instead.
+func (c *Client) UpsertContactByEmail(in Contact, ms MergeStrategy) (*Contact, bool, error) {
+	return c.UpsertContactByEmailContext(context.Background(), in, ms)
+}
+
+// UpsertContactByEmailContext ...
+func (c *Client) UpsertContactByEmailContext(ctx context.Context, in Contact, ms MergeStrategy) (*Contact, bool, error) {
+	email := normalizeEmail(in.Email())
+	if email == "" {
+		return nil, false, fmt.Errorf("upsert needs a contact with an email")
+	}
+
+	unlock := c.upserts.lock(email)
+	defer unlock()
+
+	cur, err := c.FindContactByEmailContext(ctx, email)
+	if errors.Is(err, ErrNoSuchEmail) {
+		out, cerr := c.createContact(ctx, in)
+		if cerr == nil {
+			return out, true, nil
+		}
+		if !errors.Is(cerr, ErrWrongFormat) {
+			return nil, false, cerr
+		}
+
+		// a 400 may be the duplicate check of a contact created since the
+		// lookup, in which case there is now one to merge into
+		cur, err = c.FindContactByEmailContext(ctx, email)
+		if errors.Is(err, ErrNoSuchEmail) {
+			return nil, false, cerr
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	out, err := c.mergeContact(ctx, cur, in, ms)
+	return out, false, err
+}
+
+// mergeContact merges the properties and tags of in into the existing
+// contact, only sending what changed
+func (c *Client) mergeContact(ctx context.Context, cur *Contact, in Contact, ms MergeStrategy) (*Contact, error) {
+	out := cur
+	if changed := ms.merge(&cur.Properties, in.Properties); len(changed) > 0 {
+		// multi-valued properties are sent with all their values, so the
+		// ones already there aren't replaced by the appended one
+		send := PropertyList{}
+		for _, p := range changed {
+			switch {
+			case !multiValued(p.Name):
+				send = append(send, p)
+			case len(send.All(p.Name)) == 0:
+				send = append(send, cur.Properties.All(p.Name)...)
+			}
+		}
+
+		updated, err := c.UpdateContactPropertiesContext(ctx, int64(cur.ID), Contact{Properties: send})
+		if err != nil {
+			return nil, err
+		}
+		out = updated
+	}
+
+	tags := []string{}
+	for _, t := range in.Tags {
+		if !containsString(out.Tags, t) {
+			tags = append(tags, t)
+		}
+	}
+	if len(tags) > 0 {
+		updated, err := c.UpdateContactTagsContext(ctx, int64(cur.ID), tags)
+		if err != nil {
+			return nil, err
+		}
+		out = updated
+	}
+
+	return out, nil
+}
+
+// containsString ...
+func containsString(list []string, v string) bool {
+	for _, cur := range list {
+		if cur == v {
+			return true
+		}
+	}
+	return false
+}
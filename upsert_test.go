@@ -0,0 +1,185 @@
+package agilecrm_test
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Z2hMedia/agilecrm"
+	"github.com/Z2hMedia/agilecrm/agilecrmtest"
+)
+
+// values returns the sorted values of the properties
+func values(pl agilecrm.PropertyList) []string {
+	out := []string{}
+	for _, p := range pl {
+		out = append(out, p.Value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestUpsertContactByEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy agilecrm.MergeStrategy
+		emails   []string
+		phones   []string
+		title    string
+	}{
+		{
+			name:     "overwrite",
+			strategy: agilecrm.MergeOverwrite,
+			emails:   []string{"b@example.com"},
+			phones:   []string{"555 0200"},
+			title:    "CEO",
+		},
+		{
+			name:     "keep existing",
+			strategy: agilecrm.MergeKeepExisting,
+			emails:   []string{"a@example.com"},
+			phones:   []string{"555 0100"},
+			title:    "CTO",
+		},
+		{
+			name:     "append multi",
+			strategy: agilecrm.MergeAppendMulti,
+			emails:   []string{"a@example.com", "b@example.com"},
+			phones:   []string{"555 0100", "555 0200"},
+			title:    "CEO",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cl := newClient(t, nil)
+			defer srv.Close()
+
+			cur := agilecrm.Contact{Tags: []string{"customer"}}
+			cur.SetEmail(agilecrm.SubtypeWork, "a@example.com")
+			cur.SetPhone(agilecrm.SubtypeWork, "555 0100")
+			cur.Properties.Set(agilecrm.Property{Name: agilecrm.PropTitle, Value: "CTO"})
+			stored := srv.AddContact(cur)
+
+			// the first email finds the contact, the second one and the
+			// phone have the same subtype as the stored ones
+			in := agilecrm.Contact{Tags: []string{"vip", "customer"}}
+			in.SetEmail(agilecrm.SubtypeWork, "A@example.com")
+			in.AddEmail(agilecrm.SubtypeWork, "b@example.com")
+			in.SetPhone(agilecrm.SubtypeWork, "555 0200")
+			in.Properties.Set(agilecrm.Property{Name: agilecrm.PropTitle, Value: "CEO"})
+			in.Properties.Set(agilecrm.Property{Name: agilecrm.PropCompany, Value: "Acme"})
+
+			out, created, err := cl.UpsertContactByEmail(in, tt.strategy)
+			if err != nil {
+				t.Fatalf("unexpected error; %v", err)
+			}
+			if created {
+				t.Error("existing contact reported as created")
+			}
+			if out.ID != stored.ID {
+				t.Errorf("merged into %v, want %v", out.ID, stored.ID)
+			}
+
+			got, _ := srv.Contact(stored.ID)
+			if e := values(got.Emails()); !reflect.DeepEqual(e, tt.emails) {
+				t.Errorf("emails = %v, want %v", e, tt.emails)
+			}
+			if p := values(got.Phones()); !reflect.DeepEqual(p, tt.phones) {
+				t.Errorf("phones = %v, want %v", p, tt.phones)
+			}
+			if v := got.Properties.Value(agilecrm.PropTitle, ""); v != tt.title {
+				t.Errorf("title = %q, want %q", v, tt.title)
+			}
+
+			// properties the contact doesn't have and tags are always added
+			if v := got.Properties.Value(agilecrm.PropCompany, ""); v != "Acme" {
+				t.Errorf("company = %q, want Acme", v)
+			}
+			tags := append([]string{}, got.Tags...)
+			sort.Strings(tags)
+			if !reflect.DeepEqual(tags, []string{"customer", "vip"}) {
+				t.Errorf("tags = %v, want customer and vip", tags)
+			}
+		})
+	}
+}
+
+func TestUpsertContactByEmailCreates(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	in := agilecrm.Contact{Tags: []string{"lead"}}
+	in.SetEmail("", "new@example.com")
+
+	out, created, err := cl.UpsertContactByEmail(in, agilecrm.MergeOverwrite)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if !created {
+		t.Error("new contact reported as merged")
+	}
+	if _, ok := srv.Contact(out.ID); !ok || out.Email() != "new@example.com" {
+		t.Errorf("contact %v with %v not stored", out.ID, out.Email())
+	}
+
+	// the same upsert again finds it and has nothing to change
+	before := srv.Requests()
+	again, created, err := cl.UpsertContactByEmail(in, agilecrm.MergeOverwrite)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if created || again.ID != out.ID {
+		t.Errorf("second upsert created = %v into %v, want a merge into %v", created, again.ID, out.ID)
+	}
+	if n := srv.Requests() - before; n != 1 {
+		t.Errorf("unchanged upsert made %v requests, want only the lookup", n)
+	}
+}
+
+func TestUpsertContactByEmailDuplicate(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	// the contact is created elsewhere between the lookup and the create,
+	// so the create hits the API's duplicate check
+	existing := agilecrm.Contact{}
+	existing.SetEmail("", "race@example.com")
+	stored := srv.AddContact(existing)
+	srv.Inject(agilecrmtest.Fault{Method: "GET", Route: "api/contacts/search/email/race@example.com", Status: http.StatusNoContent, Times: 1})
+
+	in := agilecrm.Contact{Tags: []string{"vip"}}
+	in.SetEmail("", "race@example.com")
+
+	out, created, err := cl.UpsertContactByEmail(in, agilecrm.MergeOverwrite)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if created || out.ID != stored.ID {
+		t.Errorf("created = %v for %v, want a merge into %v", created, out.ID, stored.ID)
+	}
+	if got, _ := srv.Contact(stored.ID); len(got.Tags) != 1 || got.Tags[0] != "vip" {
+		t.Errorf("tags = %v, want vip", got.Tags)
+	}
+	if n := len(srv.Contacts()); n != 1 {
+		t.Errorf("%v contacts stored, want 1", n)
+	}
+}
+
+func TestUpsertContactByEmailErrors(t *testing.T) {
+	srv, cl := newClient(t, nil)
+	defer srv.Close()
+
+	if _, _, err := cl.UpsertContactByEmail(agilecrm.Contact{}, agilecrm.MergeOverwrite); err == nil {
+		t.Error("expected a contact without an email to fail")
+	}
+
+	// a 400 that isn't a duplicate is returned as is
+	srv.Inject(agilecrmtest.Fault{Method: "POST", Route: "api/contacts", Status: http.StatusBadRequest, Times: 1})
+	in := agilecrm.Contact{}
+	in.SetEmail("", "bad@example.com")
+	if _, _, err := cl.UpsertContactByEmail(in, agilecrm.MergeOverwrite); err == nil {
+		t.Error("expected the rejected create to fail")
+	}
+}